@@ -0,0 +1,82 @@
+// average.go
+// Average repeated readings of a bridge before balancing it.
+
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// averageReadings replaces each run of n readable rows of sensors by
+// one sensor with the mean of each arm, so that the noise of the
+// measurement does less to the balance resistors chosen, and keeps
+// the sample standard deviation of each arm to judge the readings by.
+// Rows that could not be read are passed through in their place.
+// The last run may be short, and is averaged over what there is.
+// The averaged sensor takes the ID of its first row, or names the
+// lines it spans when the rows are named for their lines.
+func averageReadings(sensors []sensor, n int) []sensor {
+	var averaged, run []sensor
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		averaged = append(averaged, meanOf(run))
+		run = run[:0]
+	}
+	for _, s := range sensors {
+		if s.Err != nil {
+			averaged = append(averaged, s)
+			continue
+		}
+		if run = append(run, s); len(run) == n {
+			flush()
+		}
+	}
+	if len(run) > 0 {
+		warnf("the last run has only %d of the %d readings averaged", len(run), n)
+	}
+	flush()
+	return averaged
+}
+
+// meanOf returns the run of readings as one sensor, holding their mean
+// and the spread of each arm.
+func meanOf(run []sensor) sensor {
+	first, last := run[0], run[len(run)-1]
+	s := sensor{ID: first.ID, Line: first.Line, Readings: len(run)}
+	if first.ID == fmt.Sprintf("line %d", first.Line) && len(run) > 1 {
+		s.ID = fmt.Sprintf("lines %d-%d", first.Line, last.Line)
+	}
+	var mean [4]float64
+	for i := range mean {
+		var sum float64
+		for _, r := range run {
+			sum += arm(r.Bridge, i)
+		}
+		mean[i] = sum / float64(len(run))
+		if len(run) > 1 {
+			var ss float64
+			for _, r := range run {
+				d := arm(r.Bridge, i) - mean[i]
+				ss += d * d
+			}
+			s.StdDev[i] = math.Sqrt(ss / float64(len(run)-1))
+		}
+	}
+	bridge, err := npp301.NewNPP301(mean[0], mean[1], mean[2], mean[3], 1.0)
+	if err != nil {
+		s.Err = err
+		return s
+	}
+	s.Bridge = *bridge
+	return s
+}
+
+// arm returns the measured resistance of arm R1..R4, numbered from zero.
+func arm(bridge npp301.NPP301, i int) float64 {
+	return [4]float64{bridge.R1, bridge.R2, bridge.R3, bridge.R4}[i]
+}
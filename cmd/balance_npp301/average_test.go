@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+func TestAverageReadings(t *testing.T) {
+	row := func(line int, R1, R2 float64) sensor {
+		return sensor{ID: fmt.Sprintf("line %d", line), Line: line,
+			Bridge: npp301.NPP301{R1: R1, R2: R2, R3: 1005.0, R4: 1000.0}}
+	}
+	bad := sensor{ID: "line 4", Line: 4, Err: errors.New("bad row")}
+	sensors := []sensor{row(1, 1000.0, 1010.0), row(2, 1002.0, 1010.0), row(3, 1004.0, 1010.0), bad, row(5, 999.0, 1011.0)}
+	got := averageReadings(sensors, 3)
+	if len(got) != 3 {
+		t.Fatalf("got %d sensors, want 3: %+v", len(got), got)
+	}
+	first := got[0]
+	if first.ID != "lines 1-3" || first.Line != 1 || first.Readings != 3 || first.Err != nil {
+		t.Errorf("first run: got %+v", first)
+	}
+	if first.Bridge.R1 != 1002.0 || first.Bridge.R2 != 1010.0 || first.Bridge.R3 != 1005.0 {
+		t.Errorf("first run: got mean %+v", first.Bridge)
+	}
+	if math.Abs(first.StdDev[0]-2.0) > 1.0e-12 || first.StdDev[1] != 0.0 {
+		t.Errorf("first run: got standard deviations %v, want 2 for R1 and 0 for R2", first.StdDev)
+	}
+	if got[1].Err == nil || got[1].Line != 4 {
+		t.Errorf("a failed row should be passed through in its place, got %+v", got[1])
+	}
+	last := got[2]
+	if last.ID != "line 5" || last.Readings != 1 || last.Bridge.R1 != 999.0 || last.StdDev != [4]float64{} {
+		t.Errorf("short last run: got %+v", last)
+	}
+	if got := averageReadings(nil, 3); len(got) != 0 {
+		t.Errorf("no readings: got %+v", got)
+	}
+	named := []sensor{row(1, 1000.0, 1010.0), row(2, 1002.0, 1010.0)}
+	named[0].ID = "S17"
+	if got := averageReadings(named, 2); len(got) != 1 || got[0].ID != "S17" {
+		t.Errorf("a named run should keep the ID of its first row, got %+v", got)
+	}
+}
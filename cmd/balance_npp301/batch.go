@@ -0,0 +1,217 @@
+// batch.go
+// Balance a batch of sensors read from a file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// sensor is one row of a batch input file.
+// Err is set for a row that could not be read, which is reported
+// in its place in the batch rather than stopping the batch.
+// Readings, when more than one, is the number of rows averaged
+// into the bridge, and StdDev the spread of each arm over them.
+type sensor struct {
+	ID       string
+	Line     int
+	Bridge   npp301.NPP301
+	Err      error
+	Readings int
+	StdDev   [4]float64
+}
+
+// readSensors reads the measured arms of a batch of sensors.
+// Each line holds R1,R2,R3,R4 and, optionally, a sensor ID.
+// Blank lines, lines starting with # and a header line starting with R1
+// are skipped.  Sensors without an ID are named for their line number.
+// Only a failure to read the input is returned as an error.
+func readSensors(r io.Reader) ([]sensor, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var sensors []sensor
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			sensors = append(sensors, sensor{ID: fmt.Sprintf("line %d", parseErr.StartLine), Line: parseErr.StartLine, Err: err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(sensors) == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "R1") {
+			continue
+		}
+		s := sensor{ID: fmt.Sprintf("line %d", line), Line: line}
+		if len(record) == 5 && strings.TrimSpace(record[4]) != "" {
+			s.ID = strings.TrimSpace(record[4])
+		}
+		s.Bridge, s.Err = parseSensor(record)
+		sensors = append(sensors, s)
+	}
+	return sensors, nil
+}
+
+// parseSensor converts the fields R1,R2,R3,R4[,ID] of a row.
+func parseSensor(record []string) (npp301.NPP301, error) {
+	if len(record) < 4 || len(record) > 5 {
+		return npp301.NPP301{}, fmt.Errorf("expected R1,R2,R3,R4 and an optional ID, got %d fields", len(record))
+	}
+	var R [4]float64
+	for i := range R {
+		var err error
+		R[i], err = parseResistance(fmt.Sprintf("R%d", i+1), strings.TrimSpace(record[i]))
+		if err != nil {
+			return npp301.NPP301{}, err
+		}
+	}
+	bridge, err := npp301.NewNPP301(R[0], R[1], R[2], R[3], 1.0)
+	if err != nil {
+		return npp301.NPP301{}, err
+	}
+	return *bridge, nil
+}
+
+// readSensorFile reads a batch of sensors from the named file.
+func readSensorFile(name string) ([]sensor, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readSensors(f)
+}
+
+// batchStatus is the outcome for one sensor of a batch.
+type batchStatus int
+
+const (
+	solved batchStatus = iota
+	unsolved
+	failed
+)
+
+// writeBatch prints a summary block with the best balance solution for each sensor.
+// The excitation, temperature coefficients, leads and sense lines are taken from common.
+// The sensors are balanced on a pool of workers, one search each, and
+// the blocks are printed in input order once all are done.
+// It returns the number of sensors for which no solution was found
+// and the number of rows that could not be read.
+func writeBatch(w io.Writer, tf textFormat, sensors []sensor, common npp301.NPP301,
+	unbalanceTol float64, opts npp301.SearchOptions) (int, int) {
+	// Each search is serial and without progress reports,
+	// the sensors being shared among the workers.
+	opts.Workers, opts.Progress = 1, nil
+	blocks := make([]bytes.Buffer, len(sensors))
+	status := make([]batchStatus, len(sensors))
+	best := make([]npp301.NPP301, len(sensors))
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < runtime.NumCPU(); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				status[i], best[i] = writeSensor(&blocks[i], tf, sensors[i], common, unbalanceTol, opts)
+			}
+		}()
+	}
+	for i := range sensors {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+	nUnsolved, nFailed := 0, 0
+	var offsets []float64
+	for i := range blocks {
+		w.Write(blocks[i].Bytes())
+		switch status[i] {
+		case solved:
+			offsets = append(offsets, best[i].V2mV6)
+		case unsolved:
+			nUnsolved++
+		case failed:
+			nFailed++
+		}
+	}
+	writeLotSpread(w, offsets, opts.Target)
+	return nUnsolved, nFailed
+}
+
+// writeLotSpread reports how closely the best candidates of the sensors
+// of a lot match each other and the common target, as matters for
+// channels that are to read alike, when there are two or more.
+func writeLotSpread(w io.Writer, offsets []float64, target float64) {
+	if len(offsets) < 2 {
+		return
+	}
+	lo, hi := slices.Min(offsets), slices.Max(offsets)
+	fmt.Fprintf(w, "lot of %d sensors balanced to target=%v: v2mv6 from %+.1e to %+.1e V of the target, a spread of %.1e V\n",
+		len(offsets), target, lo-target, hi-target, hi-lo)
+}
+
+// writeSensor prints the block for one sensor of a batch,
+// and returns the best candidate for a sensor that is solved.
+func writeSensor(w io.Writer, tf textFormat, s sensor, common npp301.NPP301,
+	unbalanceTol float64, opts npp301.SearchOptions) (batchStatus, npp301.NPP301) {
+	if s.Err != nil {
+		if s.ID == fmt.Sprintf("line %d", s.Line) {
+			fmt.Fprintf(w, "sensor %s: Error: %v\n", s.ID, s.Err)
+		} else {
+			fmt.Fprintf(w, "sensor %s, line %d: Error: %v\n", s.ID, s.Line, s.Err)
+		}
+		return failed, npp301.NPP301{}
+	}
+	npp := s.Bridge
+	npp.Vexc, npp.TCArms, npp.TCBalance = common.Vexc, common.TCArms, common.TCBalance
+	npp.RL1, npp.RL2, npp.RL3, npp.RL4 = common.RL1, common.RL2, common.RL3, common.RL4
+	npp.RS2, npp.RS6 = common.RS2, common.RS6
+	npp.RADC = common.RADC
+	if err := npp.CheckCurrent(tf.currentLimit); err != nil {
+		if tf.currentError {
+			fmt.Fprintf(w, "sensor %s: Error: %v\n", s.ID, err)
+			return failed, npp301.NPP301{}
+		}
+		warnf("sensor %s: %v", s.ID, err)
+	}
+	warnConditioning(fmt.Sprintf("sensor %s: ", s.ID), npp)
+	npp.ComputeUnbalance()
+	fmt.Fprintf(w, "sensor %s: R1=%.1f R2=%.1f R3=%.1f R4=%.1f\n", s.ID, npp.R1, npp.R2, npp.R3, npp.R4)
+	if s.Readings > 1 {
+		fmt.Fprintf(w, "  mean of %d readings, standard deviation R1=%.2f R2=%.2f R3=%.2f R4=%.2f ohm\n",
+			s.Readings, s.StdDev[0], s.StdDev[1], s.StdDev[2], s.StdDev[3])
+	}
+	fmt.Fprintf(w, "  initial unbalance v2-v6= %v: %s\n", npp.V2mV6, unbalanceMeaning(npp.V2mV6, opts.Target, npp.AdjustsLeg34(opts.Target)))
+	result := npp.Search(unbalanceTol, opts)
+	candidates := result.Candidates
+	switch {
+	case result.AlreadyBalanced:
+		fmt.Fprintln(w, "  Bridge already balanced, no resistors needed.")
+	case len(candidates) == 0:
+		fmt.Fprintln(w, "  No candidate solutions made the cut.")
+		writeClosest(w, tf, result, opts.Target, "  ")
+		return unsolved, npp301.NPP301{}
+	default:
+		fmt.Fprintf(w, "  best of %d: %s\n", len(candidates), tf.candidate(candidates[0]))
+		warnCandidates(candidates[:1], tf)
+	}
+	return solved, candidates[0]
+}
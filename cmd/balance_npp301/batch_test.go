@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadSensors(t *testing.T) {
+	input := strings.Join([]string{
+		"R1,R2,R3,R4,ID",
+		"1000,1010,1005,1000,A1",
+		"# a comment",
+		"",
+		"1000, 1010, 1005, 1000",
+		"1000,1010,1005",
+		"1000,x,1005,1000,A4",
+		"1000,1010,1005,1000,A5,extra",
+		"1000,-5,1005,1000",
+		`1000,1010,"1005,1000`,
+	}, "\n")
+	sensors, err := readSensors(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		ID   string
+		line int
+		ok   bool
+	}{
+		{"A1", 2, true},
+		{"line 5", 5, true},
+		{"line 6", 6, false},
+		{"A4", 7, false},
+		{"line 8", 8, false},
+		{"line 9", 9, false},
+		{"line 10", 10, false},
+	}
+	if len(sensors) != len(want) {
+		t.Fatalf("got %d sensors, want %d: %+v", len(sensors), len(want), sensors)
+	}
+	for i, w := range want {
+		s := sensors[i]
+		if s.ID != w.ID || s.Line != w.line {
+			t.Errorf("sensor %d: got ID %q on line %d, want %q on line %d", i, s.ID, s.Line, w.ID, w.line)
+		}
+		if (s.Err == nil) != w.ok {
+			t.Errorf("sensor %q: got error %v, want ok=%v", s.ID, s.Err, w.ok)
+		}
+	}
+	if b := sensors[1].Bridge; b.R1 != 1000.0 || b.R2 != 1010.0 || b.R3 != 1005.0 || b.R4 != 1000.0 {
+		t.Errorf("line 5: got %+v", b)
+	}
+}
@@ -0,0 +1,76 @@
+// config.go
+// Defaults for the options, read from a file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// configConflicts lists, for each option that has alternatives, the
+// options that cannot be given with it.  An option in the config file
+// gives way to any of these given on the command line, so that the
+// command line takes precedence over the file even for an alternative.
+var configConflicts = map[string][]string{
+	"tol":            {"tol-pct"},
+	"tol-pct":        {"tol"},
+	"values":         {"series", "decades", "escalate", "compare-series"},
+	"series":         {"values", "escalate", "compare-series"},
+	"decades":        {"values"},
+	"escalate":       {"series", "values", "values-ab", "values-cd", "compare-series"},
+	"compare-series": {"series", "values", "values-ab", "values-cd", "escalate"},
+	"values-ab":      {"escalate", "compare-series"},
+	"values-cd":      {"escalate", "compare-series"},
+	"input":          {"serial-log", "interactive"},
+	"serial-log":     {"input", "interactive"},
+	"interactive":    {"input", "serial-log"},
+}
+
+// applyConfig reads a JSON object of option names and values,
+// such as {"vexc": 3.3, "tol": 1e-4, "series": "E96"},
+// from the named file and sets each option of fs that was not given
+// on the command line, nor given way to an alternative that was,
+// so that flags take precedence.
+// The measured arms are not taken from the file.  The config is applied
+// before any option is used, so that even -debug may be set in it.
+func applyConfig(fs *flag.FlagSet, name string, set map[string]bool) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var config map[string]any
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	for key, value := range config {
+		switch key {
+		case "r1", "r2", "r3", "r4", "config", "selftest":
+			return fmt.Errorf("%s: option %q cannot be set in a config file", name, key)
+		}
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("%s: unknown option %q", name, key)
+		}
+		if set[key] || slices.ContainsFunc(configConflicts[key], func(other string) bool { return set[other] }) {
+			continue
+		}
+		if err := fs.Set(key, configText(value)); err != nil {
+			return fmt.Errorf("%s: option %q: %v", name, key, err)
+		}
+	}
+	return nil
+}
+
+// configText returns a value from the config file as the text of a flag.
+// JSON numbers are written out in full, not in the exponent form of
+// fmt, so that a large count such as 1000000 still reads as an integer.
+func configText(value any) string {
+	if v, ok := value.(float64); ok {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
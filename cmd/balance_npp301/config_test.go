@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// configFlags returns a set of the options that have alternatives,
+// to apply a config file to.
+func configFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	for _, name := range []string{"tol", "series", "decades", "values", "escalate", "values-ab", "values-cd", "input", "serial-log"} {
+		fs.String(name, "", "")
+	}
+	fs.Float64("tol-pct", 0.0, "")
+	fs.Float64("span", 0.0, "")
+	fs.Int("top", 0, "")
+	fs.Bool("compare-series", false, "")
+	fs.Bool("interactive", false, "")
+	return fs
+}
+
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(name, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestApplyConfig(t *testing.T) {
+	fs := configFlags()
+	name := writeConfig(t, `{"top": 1000000, "span": 0.02, "series": "E96"}`)
+	if err := applyConfig(fs, name, map[string]bool{"series": true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Lookup("top").Value.String(); got != "1000000" {
+		t.Errorf("top = %q, want 1000000", got)
+	}
+	if got := fs.Lookup("series").Value.String(); got != "" {
+		t.Errorf("series given on the command line should not be set from the file, got %q", got)
+	}
+	for _, key := range []string{"r1", "config", "selftest", "nonesuch"} {
+		if err := applyConfig(configFlags(), writeConfig(t, `{"`+key+`": 1}`), nil); err == nil {
+			t.Errorf("expected an error for %q in the config file", key)
+		}
+	}
+}
+
+func TestApplyConfigGivesWay(t *testing.T) {
+	for _, tc := range []struct {
+		key, value, given string
+	}{
+		{"tol-pct", "1", "tol"},
+		{"tol", `"1e-4"`, "tol-pct"},
+		{"values", `"v.txt"`, "series"},
+		{"values", `"v.txt"`, "decades"},
+		{"values", `"v.txt"`, "escalate"},
+		{"values", `"v.txt"`, "compare-series"},
+		{"series", `"E96"`, "values"},
+		{"series", `"E96"`, "escalate"},
+		{"series", `"E96"`, "compare-series"},
+		{"decades", `"-1,5"`, "values"},
+		{"escalate", `"E24,E96"`, "series"},
+		{"escalate", `"E24,E96"`, "values"},
+		{"escalate", `"E24,E96"`, "values-ab"},
+		{"escalate", `"E24,E96"`, "values-cd"},
+		{"escalate", `"E24,E96"`, "compare-series"},
+		{"compare-series", "true", "series"},
+		{"compare-series", "true", "values"},
+		{"compare-series", "true", "values-ab"},
+		{"compare-series", "true", "values-cd"},
+		{"compare-series", "true", "escalate"},
+		{"values-ab", `"E48"`, "escalate"},
+		{"values-ab", `"E48"`, "compare-series"},
+		{"values-cd", `"E48"`, "escalate"},
+		{"values-cd", `"E48"`, "compare-series"},
+		{"input", `"sensors.csv"`, "serial-log"},
+		{"input", `"sensors.csv"`, "interactive"},
+		{"serial-log", `"board.log"`, "input"},
+		{"serial-log", `"board.log"`, "interactive"},
+		{"interactive", "true", "input"},
+		{"interactive", "true", "serial-log"},
+	} {
+		fs := configFlags()
+		before := fs.Lookup(tc.key).Value.String()
+		name := writeConfig(t, `{"`+tc.key+`": `+tc.value+`}`)
+		if err := applyConfig(fs, name, map[string]bool{tc.given: true}); err != nil {
+			t.Errorf("%s in the file with -%s: %v", tc.key, tc.given, err)
+			continue
+		}
+		if got := fs.Lookup(tc.key).Value.String(); got != before {
+			t.Errorf("%s in the file should give way to -%s, but was set to %q", tc.key, tc.given, got)
+		}
+		if err := applyConfig(fs, name, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := fs.Lookup(tc.key).Value.String(); got == before {
+			t.Errorf("%s in the file was not set without -%s", tc.key, tc.given)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// diag.go
+// Diagnostic messages, kept apart from the results on stdout.
+
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// debug turns on the detail of each step of the search.
+var debug bool
+
+// debugf logs the detail of the search, when asked for with -debug.
+func debugf(format string, args ...any) {
+	if debug {
+		log.Printf("debug: "+format, args...)
+	}
+}
+
+// warnf logs a warning on stderr, where it cannot corrupt piped output.
+func warnf(format string, args ...any) {
+	log.Printf("warning: "+format, args...)
+}
+
+// warnPower flags the candidates with a balance resistor
+// dissipating more than powerLimit watts, zero disabling the check.
+func warnPower(candidates []npp301.NPP301, powerLimit float64) {
+	if powerLimit == 0.0 {
+		return
+	}
+	for _, c := range candidates {
+		if p := c.Dissipation().MaxBalance(); p > powerLimit {
+			warnf("RA=%s RB=%s RC=%s RD=%s: balance resistor dissipates %.3g W, above %.3g W",
+				seriesText(c.RA, c.RA2), formatOhms(c.RB), seriesText(c.RC, c.RC2), formatOhms(c.RD), p, powerLimit)
+		}
+	}
+}
+
+// warnTouchy flags the candidates whose balance resistance is within
+// factor of the arm it is in series with, zero disabling the check,
+// as such a trim swamps the arm and the result is touchy.
+func warnTouchy(candidates []npp301.NPP301, factor float64) {
+	if factor == 0.0 {
+		return
+	}
+	for _, c := range candidates {
+		if ratio := c.TrimRatio(); ratio*factor > 1.0 {
+			warnf("RA=%s RB=%s RC=%s RD=%s: balance resistance is %.2g of its arm, within a factor of %g; "+
+				"the trim is touchy, and a gentler one needs arms matched more closely, such as by -arm-swap",
+				seriesText(c.RA, c.RA2), formatOhms(c.RB), seriesText(c.RC, c.RC2), formatOhms(c.RD), ratio, factor)
+		}
+	}
+}
+
+// warnCandidates gives the warnings that apply to each candidate shown.
+func warnCandidates(candidates []npp301.NPP301, tf textFormat) {
+	warnPower(candidates, tf.powerLimit)
+	warnTouchy(candidates, tf.touchyFactor)
+}
+
+// warnConditioning warns of each problem found by Conditioning,
+// labelled by prefix, as the computed output may not be trusted.
+func warnConditioning(prefix string, npp npp301.NPP301) {
+	if err := npp.Conditioning(); err != nil {
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			warnf("%s%s; the computed output may not be trusted", prefix, problem)
+		}
+	}
+}
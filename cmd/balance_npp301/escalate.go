@@ -0,0 +1,101 @@
+// escalate.go
+// Search the coarser series first, falling back to finer ones,
+// or search each series in turn to compare them side by side.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// seriesStep is one series of an escalating search, with its values.
+type seriesStep struct {
+	name   string
+	values []float64
+}
+
+// parseEscalation converts the text of the -escalate option, the series
+// to try in turn such as E24,E96, to their values over the decades,
+// limited to Rmin..Rmax as for -min-resistor and -max-resistor.
+func parseEscalation(text string, minExp, maxExp int, Rmin, Rmax float64) ([]seriesStep, error) {
+	var steps []seriesStep
+	for _, name := range strings.Split(text, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		values, err := npp301.SeriesRange(name, minExp, maxExp)
+		if err != nil {
+			return nil, err
+		}
+		if Rmin != 0.0 || Rmax != 0.0 {
+			if values = npp301.ValuesInRange(values, Rmin, Rmax); len(values) == 0 {
+				return nil, fmt.Errorf("no %s values from %v to %v ohm", name, Rmin, Rmax)
+			}
+		}
+		steps = append(steps, seriesStep{name, values})
+	}
+	return steps, nil
+}
+
+// searchEscalating searches with each series in turn, stopping at the
+// first that gives a candidate, and returns the result with the index
+// of the series that gave it, or of the last series tried.
+// Rvalues is left set to the values of that series.
+func searchEscalating(npp npp301.NPP301, unbalanceTol float64, opts npp301.SearchOptions,
+	steps []seriesStep) (npp301.SearchResult, int) {
+	var result npp301.SearchResult
+	for i, step := range steps {
+		npp301.Rvalues = step.values
+		debugf("searching series %s, %d values", step.name, len(step.values))
+		result = npp.Search(unbalanceTol, opts)
+		if len(result.Candidates) > 0 {
+			return result, i
+		}
+	}
+	return result, len(steps) - 1
+}
+
+// compareSeries lists the standard series compared by -compare-series,
+// from the coarsest to the finest.
+const compareSeries = "E12,E24,E48,E96,E192"
+
+// writeSeriesComparison searches with each series in turn and prints
+// a table of the candidates found and of the combination that came
+// closest to the target, so as to judge whether a finer series, with
+// its tighter parts, is worth fitting.
+// Rvalues is left set to the values of the last series.
+func writeSeriesComparison(w io.Writer, tf textFormat, npp npp301.NPP301, unbalanceTol float64,
+	opts npp301.SearchOptions, steps []seriesStep) {
+	fmt.Fprintf(w, "%-6s %7s %10s %12s  %s\n", "series", "values", "candidates", "best v2mv6", "balance resistors")
+	for _, step := range steps {
+		npp301.Rvalues = step.values
+		result := npp.Search(unbalanceTol, opts)
+		if result.Tried == 0 {
+			fmt.Fprintf(w, "%-6s %7d %10d %12s  %s\n", step.name, len(step.values), 0, "-", "none tried")
+			continue
+		}
+		c := result.Closest
+		fmt.Fprintf(w, "%-6s %7d %10d %12s  %s=%s %s=%s %s=%s %s=%s\n", step.name, len(step.values),
+			len(result.Candidates), tf.num("%.3e", c.V2mV6),
+			tf.ref("RA"), seriesText(c.RA, c.RA2), tf.ref("RB"), formatOhms(c.RB),
+			tf.ref("RC"), seriesText(c.RC, c.RC2), tf.ref("RD"), formatOhms(c.RD))
+	}
+}
+
+// escalationNote says which series of an escalating search gave
+// the result, and which coarser series did not suffice.
+func escalationNote(steps []seriesStep, used int, solved bool) string {
+	var tried []string
+	for _, step := range steps[:used] {
+		tried = append(tried, step.name)
+	}
+	switch {
+	case !solved:
+		return fmt.Sprintf("no series gave a candidate, the finest tried being %s", steps[used].name)
+	case used == 0:
+		return fmt.Sprintf("candidates from series %s", steps[0].name)
+	}
+	return fmt.Sprintf("candidates from the finer series %s, as %s gave none", steps[used].name, strings.Join(tried, ", "))
+}
@@ -0,0 +1,53 @@
+// histogram.go
+// Distribution of the offsets achievable with the resistor values.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// histogramWidth is the length of the bar for the fullest bin.
+const histogramWidth = 50
+
+// writeHistogram bins the outputs of all the combinations tried,
+// within tolerance or not, and prints the count in each bin
+// with a bar, so that it can be seen where the achievable offsets cluster.
+// When every combination gives the same offset, there is one bin.
+func writeHistogram(w io.Writer, combinations []npp301.NPP301, bins int) {
+	if len(combinations) == 0 || bins <= 0 {
+		fmt.Fprintln(w, "No combinations to bin.")
+		return
+	}
+	lo, hi := combinations[0].V2mV6, combinations[0].V2mV6
+	for _, c := range combinations {
+		lo, hi = min(lo, c.V2mV6), max(hi, c.V2mV6)
+	}
+	if hi == lo {
+		// Every combination gives the one offset, so bins of no width
+		// would say nothing more than a single bin.
+		bins = 1
+	}
+	width := (hi - lo) / float64(bins)
+	counts := make([]int, bins)
+	for _, c := range combinations {
+		i := bins - 1
+		if width > 0.0 {
+			i = min(int((c.V2mV6-lo)/width), bins-1)
+		}
+		counts[i]++
+	}
+	fullest := 0
+	for _, n := range counts {
+		fullest = max(fullest, n)
+	}
+	fmt.Fprintf(w, "Offsets of %d combinations, v2-v6 in volts:\n", len(combinations))
+	for i, n := range counts {
+		bar := strings.Repeat("#", (n*histogramWidth+fullest-1)/fullest)
+		fmt.Fprintf(w, "%11.3e .. %11.3e %7d %s\n", lo+float64(i)*width, lo+float64(i+1)*width, n, bar)
+	}
+}
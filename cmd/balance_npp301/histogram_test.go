@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+func TestWriteHistogram(t *testing.T) {
+	offsets := func(vs ...float64) []npp301.NPP301 {
+		combinations := make([]npp301.NPP301, len(vs))
+		for i, v := range vs {
+			combinations[i].V2mV6 = v
+		}
+		return combinations
+	}
+	for _, tc := range []struct {
+		name         string
+		combinations []npp301.NPP301
+		bins         int
+		counts       []string
+	}{
+		{"spread", offsets(0.0, 1.0, 2.0, 3.0, 3.5, 4.0), 4, []string{"1", "1", "1", "3"}},
+		{"one offset", offsets(1.0e-3, 1.0e-3, 1.0e-3), 4, []string{"3"}},
+		{"one combination", offsets(-2.0e-3), 5, []string{"1"}},
+	} {
+		var b strings.Builder
+		writeHistogram(&b, tc.combinations, tc.bins)
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != len(tc.counts)+1 {
+			t.Errorf("%s: got %d bins, want %d:\n%s", tc.name, len(lines)-1, len(tc.counts), b.String())
+			continue
+		}
+		for i, want := range tc.counts {
+			fields := strings.Fields(lines[i+1])
+			if len(fields) < 4 || fields[3] != want {
+				t.Errorf("%s: bin %d is %q, want a count of %s", tc.name, i, lines[i+1], want)
+			}
+		}
+	}
+	var b strings.Builder
+	writeHistogram(&b, nil, 4)
+	if !strings.Contains(b.String(), "No combinations") {
+		t.Errorf("no combinations: got %q", b.String())
+	}
+}
@@ -0,0 +1,91 @@
+// interactive.go
+// Balance one bridge after another, as they are typed in on the bench.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// interactivePrompt is printed before each line is read.
+const interactivePrompt = "R1 R2 R3 R4 [unbalanceTol]> "
+
+// runInteractive reads lines of R1 R2 R3 R4 and, optionally, unbalanceTol,
+// separated by spaces or commas, and prints the best candidate for each
+// until the end of the input or a line of quit.  A line that cannot be
+// parsed is reported and the next one read, as is a bridge drawing too
+// much current when that is an error.  The resistor values are
+// set up once, for all of the bridges; the excitation, temperature
+// coefficients, leads and sense lines are taken from common.
+func runInteractive(r io.Reader, w io.Writer, tf textFormat, common npp301.NPP301,
+	unbalanceTol float64, opts npp301.SearchOptions) error {
+	// A progress report would be mixed in with the prompt.
+	opts.Progress = nil
+	scanner := bufio.NewScanner(r)
+	for {
+		fmt.Fprint(w, interactivePrompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			break
+		}
+		fields := strings.FieldsFunc(scanner.Text(), func(c rune) bool {
+			return c == ' ' || c == '\t' || c == ','
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 && (fields[0] == "quit" || fields[0] == "exit") {
+			break
+		}
+		if len(fields) != 4 && len(fields) != 5 {
+			fmt.Fprintf(w, "Error: expected R1 R2 R3 R4 [unbalanceTol], got %d fields\n", len(fields))
+			continue
+		}
+		tol := unbalanceTol
+		if len(fields) == 5 {
+			var err error
+			if tol, err = parseArg("unbalanceTol", fields[4]); err != nil {
+				fmt.Fprintln(w, "Error:", err)
+				continue
+			}
+			fields = fields[:4]
+		}
+		bridge, err := parseSensor(fields)
+		if err != nil {
+			fmt.Fprintln(w, "Error:", err)
+			continue
+		}
+		npp := bridge
+		npp.Vexc, npp.TCArms, npp.TCBalance = common.Vexc, common.TCArms, common.TCBalance
+		npp.RL1, npp.RL2, npp.RL3, npp.RL4 = common.RL1, common.RL2, common.RL3, common.RL4
+		npp.RS2, npp.RS6 = common.RS2, common.RS6
+		npp.RADC = common.RADC
+		if err := npp.CheckCurrent(tf.currentLimit); err != nil {
+			if tf.currentError {
+				fmt.Fprintln(w, "Error:", err)
+				continue
+			}
+			warnf("%v", err)
+		}
+		warnConditioning("", npp)
+		npp.ComputeUnbalance()
+		fmt.Fprintf(w, "initial unbalance v2-v6= %v: %s\n", npp.V2mV6,
+			unbalanceMeaning(npp.V2mV6, opts.Target, npp.AdjustsLeg34(opts.Target)))
+		result := npp.Search(tol, opts)
+		if result.AlreadyBalanced {
+			fmt.Fprintln(w, "Bridge already balanced, no resistors needed.")
+			continue
+		}
+		tf.unbalanceTol = tol
+		writeBest(w, tf, result, opts.Target)
+		if len(result.Candidates) > 0 {
+			warnCandidates(result.Candidates[:1], tf)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+func TestRunInteractive(t *testing.T) {
+	input := strings.Join([]string{
+		"1000 1010 1005",
+		"1000 1010 1005 1000 1e-4 A1",
+		"1000 x 1005 1000",
+		"1 1 1 1",
+		"1000,1010,1005,1000",
+		"quit",
+		"1000 1010 1005 1000",
+	}, "\n")
+	tf := textFormat{currentLimit: 0.05, currentError: true}
+	var b strings.Builder
+	err := runInteractive(strings.NewReader(input), &b, tf, npp301.NPP301{Vexc: 1.0}, 1.0e-4, npp301.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"Error: expected R1 R2 R3 R4 [unbalanceTol], got 3 fields",
+		"Error: expected R1 R2 R3 R4 [unbalanceTol], got 6 fields",
+		"Error: could not parse R2",
+		"Error: bridge draws 1 A",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the output:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "initial unbalance"); n != 1 {
+		t.Errorf("expected one bridge balanced before quit, got %d:\n%s", n, out)
+	}
+}
@@ -0,0 +1,930 @@
+// main.go
+// Command balance_npp301 takes the measured bridge resistances
+// from the command line and prints our options for balance resistors.
+// Peter J. 2025-03-13
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// parseArg converts the command-line text for the named parameter.
+func parseArg(name, text string) (float64, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0.0, fmt.Errorf("could not parse %s from %q", name, text)
+	}
+	return value, nil
+}
+
+// parseResistance converts the command-line text for a bridge arm.
+// Only positive resistances are physically meaningful.
+func parseResistance(name, text string) (float64, error) {
+	value, err := parseArg(name, text)
+	if err != nil {
+		return 0.0, err
+	}
+	if value <= 0.0 {
+		return 0.0, fmt.Errorf("%s must be a positive resistance, got %q", name, text)
+	}
+	return value, nil
+}
+
+// parseDecades converts the text min,max of the -decades option.
+func parseDecades(text string) (int, int, error) {
+	parts := strings.Split(text, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected decades as min,max, got %q", text)
+	}
+	minExp, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	maxExp, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("could not parse decades from %q", text)
+	}
+	return minExp, maxExp, nil
+}
+
+// parseCheck converts the text RA,RB,RC,RD of the -check option.
+// A zero value is a resistor that is not fitted.
+func parseCheck(text string) ([4]float64, error) {
+	var R [4]float64
+	parts := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(parts) != len(R) {
+		return R, fmt.Errorf("expected the balance resistors as RA,RB,RC,RD, got %q", text)
+	}
+	for i, name := range []string{"RA", "RB", "RC", "RD"} {
+		value, err := parseArg(name, parts[i])
+		if err != nil {
+			return R, err
+		}
+		if value < 0.0 {
+			return R, fmt.Errorf("%s must not be negative, got %q", name, parts[i])
+		}
+		R[i] = value
+	}
+	return R, nil
+}
+
+// parseLeads converts the text of the -leads option, either one
+// lead resistance for all four arms or the four as RL1,RL2,RL3,RL4.
+func parseLeads(text string) ([4]float64, error) {
+	var RL [4]float64
+	parts := strings.Split(text, ",")
+	if len(parts) != 1 && len(parts) != len(RL) {
+		return RL, fmt.Errorf("expected one lead resistance or RL1,RL2,RL3,RL4, got %q", text)
+	}
+	for i := range RL {
+		part := parts[0]
+		if len(parts) == len(RL) {
+			part = parts[i]
+		}
+		value, err := parseArg(fmt.Sprintf("RL%d", i+1), strings.TrimSpace(part))
+		if err != nil {
+			return RL, err
+		}
+		if value < 0.0 {
+			return RL, fmt.Errorf("RL%d must not be negative, got %q", i+1, part)
+		}
+		RL[i] = value
+	}
+	return RL, nil
+}
+
+// parseRefs converts the text of the -schematic-ref option, a list of
+// name=designator such as RA=R12,RB=R13, to the designators by name.
+// Only the balance resistor positions may be named.
+func parseRefs(text string) (map[string]string, error) {
+	refs := map[string]string{}
+	if text == "" {
+		return refs, nil
+	}
+	for _, part := range strings.Split(text, ",") {
+		name, ref, ok := strings.Cut(strings.TrimSpace(part), "=")
+		name, ref = strings.TrimSpace(name), strings.TrimSpace(ref)
+		if !ok || ref == "" {
+			return nil, fmt.Errorf("expected a designator as name=ref, such as RA=R12, got %q", part)
+		}
+		switch name {
+		case "RA", "RB", "RC", "RD", "RA2", "RC2":
+		default:
+			return nil, fmt.Errorf("unknown balance resistor %q; expected RA, RB, RC, RD, RA2 or RC2", name)
+		}
+		refs[name] = ref
+	}
+	return refs, nil
+}
+
+// parseSense converts the text of the -sense option, the resistances
+// of the sense lines as one value for both or as RS2,RS6.
+func parseSense(text string) ([2]float64, error) {
+	var RS [2]float64
+	parts := strings.Split(text, ",")
+	if len(parts) != 1 && len(parts) != len(RS) {
+		return RS, fmt.Errorf("expected one sense-line resistance or RS2,RS6, got %q", text)
+	}
+	for i, name := range []string{"RS2", "RS6"} {
+		part := parts[0]
+		if len(parts) == len(RS) {
+			part = parts[i]
+		}
+		value, err := parseArg(name, strings.TrimSpace(part))
+		if err != nil {
+			return RS, err
+		}
+		if value < 0.0 {
+			return RS, fmt.Errorf("%s must not be negative, got %q", name, part)
+		}
+		RS[i] = value
+	}
+	return RS, nil
+}
+
+// parseExcitations converts the text of the -vexc-also option,
+// the other excitation voltages at which the bridge is run, such as 5
+// or 3.3,5, each in volts and nonzero.  Empty text gives none.
+func parseExcitations(text string) ([]float64, error) {
+	if text == "" {
+		return nil, nil
+	}
+	var excitations []float64
+	for _, part := range strings.Split(text, ",") {
+		value, err := parseArg("vexc", strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if value == 0.0 {
+			return nil, fmt.Errorf("each excitation of -vexc-also must be nonzero, got %q", part)
+		}
+		excitations = append(excitations, value)
+	}
+	return excitations, nil
+}
+
+// readValuesFile reads the resistor values on hand from the named file.
+func readValuesFile(name string) ([]float64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	values, err := npp301.ReadValues(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return values, nil
+}
+
+// readCostFile reads a price list of resistor values from the named file.
+func readCostFile(name string, flat float64) (npp301.CostTable, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return npp301.CostTable{}, err
+	}
+	defer f.Close()
+	table, err := npp301.ReadCostTable(f, flat)
+	if err != nil {
+		return npp301.CostTable{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return table, nil
+}
+
+// progressInterval is the time between progress reports, and the time
+// a search runs before the first report, so that quick searches are silent.
+const progressInterval = time.Second
+
+// newProgress returns a progress reporter for the search
+// that writes the percent done to stderr at progressInterval.
+func newProgress() func(done, total int) {
+	next := time.Now().Add(progressInterval)
+	return func(done, total int) {
+		if now := time.Now(); now.After(next) {
+			log.Printf("progress: %d%% of the search done", 100*done/total)
+			next = now.Add(progressInterval)
+		}
+	}
+}
+
+// legValues converts the text of the -values-ab and -values-cd options,
+// either the name of a series, over the decades minExp to maxExp,
+// or the name of a file of values.
+func legValues(text string, minExp, maxExp int) ([]float64, error) {
+	if values, err := npp301.SeriesRange(text, minExp, maxExp); err == nil {
+		return values, nil
+	}
+	return readValuesFile(text)
+}
+
+// exitNoSolution is the exit status when no candidate made the cut,
+// distinct from the status 1 for errors, so that scripts can branch on it.
+const exitNoSolution = 2
+
+// positionalError explains what is wrong with n positional arguments,
+// naming what is missing for the common mistakes.  Only unbalanceTol
+// may be given positionally when the bridges come from elsewhere.
+func positionalError(n int, elsewhere bool) error {
+	const hint = "; run with -h for the usage"
+	switch {
+	case elsewhere:
+		return fmt.Errorf("with -input, -serial-log or -interactive, give only unbalanceTol, in volts, as an argument, got %d arguments%s", n, hint)
+	case n == 4:
+		return fmt.Errorf("missing unbalanceTol after R1 R2 R3 R4; give the acceptable |v2-v6| in volts, such as 1e-4%s", hint)
+	case n < 4:
+		return fmt.Errorf("got %d of the arguments R1 R2 R3 R4 unbalanceTol, the arms in ohms and the tolerance in volts%s", n, hint)
+	}
+	return fmt.Errorf("got %d arguments, more than R1 R2 R3 R4 unbalanceTol; options go before the arguments%s", n, hint)
+}
+
+// exitWithError reports the error and stops the program.
+func exitWithError(err error) {
+	log.Print("Error: ", err)
+	os.Exit(1)
+}
+
+func main() {
+	series := flag.String("series", "E24", "resistor series for the balance resistors: E12, E24, E48, E96 or E192")
+	decades := flag.String("decades", fmt.Sprintf("%d,%d", npp301.DefaultMinExp, npp301.DefaultMaxExp),
+		"min,max decade exponents for the resistor values; 0,4 gives 1 ohm to 91k ohm in E24 (a wider range increases runtime)")
+	escalate := flag.String("escalate", "", "series to try in turn, such as E24,E96, falling back to the next only when no candidate passes; in place of -series")
+	compareSeriesFlag := flag.Bool("compare-series", false, "search with each of "+compareSeries+" in turn and print the best offset of each, side by side")
+	valuesFile := flag.String("values", "", "file of resistor values on hand, one per line, to use in place of -series and -decades")
+	valuesAB := flag.String("values-ab", "", "series name or file of values for RA and RB only, in place of those for both legs")
+	valuesCD := flag.String("values-cd", "", "series name or file of values for RC and RD only, in place of those for both legs")
+	minResistor := flag.Float64("min-resistor", 0.0, "smallest balance resistor value to use, in ohms (0 for no limit)")
+	maxResistor := flag.Float64("max-resistor", 0.0, "largest balance resistor value to use, in ohms (0 for no limit)")
+	top := flag.Int("top", 0, "print only the best N candidates (0 prints all)")
+	vexc := flag.Float64("vexc", 1.0, "excitation voltage across the bridge, in volts; negative for a bridge wired reversed")
+	vexcAlso := flag.String("vexc-also", "", "other excitation voltages at which candidates must also be within tolerance, such as 5 or 3.3,5")
+	single := flag.Bool("single", false, "also try a single balance resistor in place of a parallel pair")
+	seriesPairs := flag.Bool("series-pairs", false, "also try a balance position made of two values in series, in place of a parallel pair")
+	halfBridge := flag.Bool("half-bridge", false, "treat R3 and R4 as the fixed reference resistors of a half-bridge and trim only RA/RB")
+	symmetric := flag.Bool("symmetric", false, "try only matched pairs, RA=RB and RC=RD, so that each leg uses two of the same part")
+	bothLegs := flag.Bool("both-legs", false, "also try balance resistors on both legs together")
+	format := flag.String("format", "text", "output format: text, csv, json, or env for KEY=VALUE lines of the best candidate for a shell")
+	interactive := flag.Bool("interactive", false, "read R1 R2 R3 R4 [unbalanceTol] lines from stdin and print the best candidate for each, until EOF")
+	input := flag.String("input", "", "CSV file of R1,R2,R3,R4[,ID] lines; print the best solution for each sensor")
+	average := flag.Int("average", 0, "with -input or -serial-log, balance the mean of each run of this many readings, reporting their standard deviation (0 disables)")
+	serialLog := flag.String("serial-log", "", "serial log of the characterizer board, or - for stdin; print the best solution for each reading of r1..r4")
+	mcSamples := flag.Int("mc", 0, "number of Monte Carlo samples of the best candidate with toleranced balance resistors")
+	mcTol := flag.Float64("mc-tol", 1.0, "balance resistor tolerance for the Monte Carlo samples, in percent")
+	seed := flag.Int64("seed", 0, "seed for the Monte Carlo samples; the same seed and inputs give the same statistics (0 seeds from the time)")
+	mcNormal := flag.Bool("mc-normal", false, "draw Monte Carlo samples from a normal distribution, tolerance as 3 sigma, rather than uniform")
+	worstCase := flag.Float64("worst-case", 0.0, "reject candidates whose worst case, over this balance resistor tolerance in percent, exceeds unbalanceTol")
+	maxTrim := flag.Float64("max-trim", 0.0, "reject candidates whose balance resistance, RAB or RCD, is above this, in ohms (0 disables)")
+	minTrim := flag.Float64("min-trim", 0.0, "reject candidates whose balance resistance, RAB or RCD, is below this, in ohms (0 disables)")
+	jitter := flag.Float64("jitter", 0.0, "reject candidates that fail with their balance resistors at plausible real values within this tolerance in percent, such as 1")
+	tcArms := flag.Float64("tc-arms", 0.0, "temperature coefficient of the bridge arms, in ppm/degC")
+	tcBalance := flag.Float64("tc-balance", 0.0, "temperature coefficient of the balance resistors, in ppm/degC")
+	tempCal := flag.Float64("temp-cal", 25.0, "calibration temperature, in degC")
+	tempMin := flag.Float64("temp-min", -20.0, "lowest operating temperature, in degC")
+	tempMax := flag.Float64("temp-max", 85.0, "highest operating temperature, in degC")
+	minimizeParts := flag.Bool("minimize-parts", false, "order candidates by the number of distinct resistor values, fewest first")
+	sortDrift := flag.Bool("sort-drift", false, "order candidates by their worst output over the operating temperature range")
+	currentLimit := flag.Float64("current-limit", 0.05, "warn when the bridge draws more than this from the excitation, in amps (0 disables)")
+	currentError := flag.Bool("current-error", false, "make a bridge current above -current-limit an error rather than a warning")
+	costFile := flag.String("cost", "", "price list file of value,price lines; order candidates by the price of their balance resistors, cheapest first")
+	costDefault := flag.Float64("cost-default", 0.01, "price of a resistor value missing from the -cost price list")
+	touchyFactor := flag.Float64("touchy-factor", 5.0, "flag candidates whose balance resistance is within this factor of the arm it is in series with (0 disables)")
+	powerLimit := flag.Float64("power-limit", 0.1, "flag candidates with a balance resistor dissipating more than this, in watts (0 disables)")
+	configFile := flag.String("config", "", "JSON file of defaults for the options, such as vexc, tol and series; flags take precedence")
+	selftest := flag.Bool("selftest", false, "balance a built-in example bridge, check the known answer and print PASS or FAIL")
+	noSummary := flag.Bool("no-summary", false, "omit the summary of combinations tried and passed from the text output")
+	stream := flag.Bool("stream", false, "write each CSV row as the candidate is found, in no particular order; sorting and -top are unavailable")
+	report := flag.String("report", "", "also write a build record of the best candidate, for archiving, to this file")
+	netlist := flag.String("netlist", "", "also write a SPICE netlist, for LTspice, of the bridge with the best candidate to this file")
+	header := flag.Bool("header", false, "start the text or CSV output with # lines recording the time, command and settings")
+	debugFlag := flag.Bool("debug", false, "log the detail of each step of the search on stderr")
+	quiet := flag.Bool("quiet", false, "do not report the progress of a long search on stderr")
+	roundDisplay := flag.Int("round-display", 0, "significant figures for the outputs and balance resistances in the text output (0 for the usual rounding)")
+	verbose := flag.Bool("verbose", false, "show v2, v6, the leg currents and RAB/RCD for each candidate")
+	table := flag.Bool("table", false, "line up the candidates listed in the text output in columns")
+	histogram := flag.Int("histogram", 0, "print a histogram, in this many bins, of the offsets of all combinations tried, ignoring the tolerance")
+	histMax := flag.Float64("hist-max", 0.0, "bin only the offsets within this many volts of the target (0 bins them all)")
+	armSwap := flag.Bool("arm-swap", false, "skip the search and suggest replacing one arm with the nearest value in the series")
+	trimpot := flag.Float64("trimpot", 0.0, "skip the search and give the wiper setting of a trimmer of this value, in ohms, in place of fixed resistors")
+	compare := flag.String("compare", "", "skip the search and compare two sets of balance resistors given as RA,RB,RC,RD/RA,RB,RC,RD")
+	sweep := flag.String("sweep", "", "skip the search and tabulate the output as this balance resistor, such as RA, sweeps the series")
+	hold := flag.String("hold", "0,0,0,0", "balance resistors RA,RB,RC,RD held fixed during -sweep (0 for not fitted)")
+	check := flag.String("check", "", "skip the search and report the output with the balance resistors RA,RB,RC,RD (0 for not fitted)")
+	best := flag.Bool("best", false, "print only the best candidate, as the resistors to fit, or the closest combination if none makes the cut")
+	target := flag.Float64("target", 0.0, "bridge output v2-v6 sought, in volts, for a deliberately offset bridge")
+	span := flag.Float64("span", 0.0, "sensor sensitivity, in mV/V per unit pressure, for reporting the output over the pressure range, the offset in ppm of full scale and the span lost to the trim")
+	pMin := flag.Float64("p-min", 0.0, "lowest pressure of the range, in the units of -span")
+	pMax := flag.Float64("p-max", 100.0, "highest pressure of the range, in the units of -span")
+	outMin := flag.Float64("out-min", 0.0, "lowest amplifier output, in volts, for the gain recommended with -span")
+	outMax := flag.Float64("out-max", 3.3, "highest amplifier output, in volts, for the gain recommended with -span")
+	adcVref := flag.Float64("adc-vref", 0.0, "ADC reference, in volts, to show the offset in LSBs (0 disables)")
+	adcBits := flag.Int("adc-bits", 12, "ADC resolution, in bits, for the offset in LSBs")
+	gain := flag.Float64("gain", 0.0, "amplifier gain ahead of the ADC; 0 uses the gain recommended for -span, or else 1")
+	zout := flag.Bool("zout", false, "show the source resistance, in ohms, seen at pins 2 and 6 for each candidate")
+	schematicRef := flag.String("schematic-ref", "", "reference designators for the text output, such as RA=R12,RB=R13,RC=R14,RD=R15")
+	sense := flag.String("sense", "0", "resistance of separate sense lines from pins 2 and 6, in ohms, as one value or RS2,RS6")
+	adcInput := flag.Float64("adc-input", 0.0, "input resistance of the ADC reading pins 2 and 6, in ohms, to ground, so that the offset is as the ADC reads it (0 disables)")
+	leads := flag.String("leads", "0", "lead resistance added to each arm, in ohms, as one value for all four or RL1,RL2,RL3,RL4")
+	var armFlags [4]*string
+	for i := range armFlags {
+		armFlags[i] = flag.String(fmt.Sprintf("r%d", i+1), "", fmt.Sprintf("measured resistance of arm R%d, in ohms", i+1))
+	}
+	tolFlag := flag.String("tol", "1.0e-4", "unbalanceTol, the acceptable |v2-v6|, in volts")
+	tolPct := flag.Float64("tol-pct", 0.0, "unbalanceTol as a percentage of the full-scale span given by -span, in place of -tol")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		name := filepath.Base(os.Args[0])
+		fmt.Fprintf(out, "Usage: %s [options] -r1 R1 -r2 R2 -r3 R3 -r4 R4 [-tol unbalanceTol]\n", name)
+		fmt.Fprintf(out, "       %s [options] R1 R2 R3 R4 unbalanceTol\n", name)
+		fmt.Fprintf(out, "       %s [options] -input file.csv [-tol unbalanceTol]\n", name)
+		fmt.Fprintf(out, "       %s [options] -interactive [-tol unbalanceTol]\n", name)
+		fmt.Fprintln(out, "Given the measured bridge resistances, compute our options for balance resistors.")
+		fmt.Fprintln(out, "R1..R4 are the measured arms in ohms; unbalanceTol is the acceptable |v2-v6| in volts.")
+		fmt.Fprintf(out, "Example: %s 1000 1010 1005 1000 1e-4\n", name)
+		fmt.Fprintf(out, "The exit status is 0 when a solution is found, %d when none makes the cut, and 1 on error.\n", exitNoSolution)
+		fmt.Fprintln(out, "Options:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+	if *selftest {
+		if !selfTest(os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if *configFile != "" {
+		// A positional unbalanceTol comes last, however many arguments
+		// there are, and takes precedence over the file as -tol does.
+		given := set
+		if flag.NArg() > 0 {
+			given = maps.Clone(set)
+			given["tol"] = true
+		}
+		if err := applyConfig(flag.CommandLine, *configFile, given); err != nil {
+			exitWithError(err)
+		}
+	}
+	debug = *debugFlag
+	// The measured arms and tolerance may be given as flags or,
+	// as they were originally, as positional arguments.
+	args := flag.Args()
+	armTexts := [4]string{*armFlags[0], *armFlags[1], *armFlags[2], *armFlags[3]}
+	tolText := *tolFlag
+	tolGiven := set["tol"]
+	elsewhere := *input != "" || *serialLog != "" || *interactive
+	switch {
+	case len(args) == 0:
+	case elsewhere && len(args) == 1:
+		tolText = args[0]
+		tolGiven = true
+	case !elsewhere && len(args) == 5:
+		for i := range armTexts {
+			if set[fmt.Sprintf("r%d", i+1)] {
+				exitWithError(fmt.Errorf("give R%d either as -r%d or as a positional argument, not both", i+1, i+1))
+			}
+			armTexts[i] = args[i]
+		}
+		tolText = args[4]
+		tolGiven = true
+	default:
+		exitWithError(positionalError(len(args), elsewhere))
+	}
+	if !elsewhere {
+		for i, text := range armTexts {
+			if text == "" {
+				exitWithError(fmt.Errorf("missing measured resistance R%d; use -r%d", i+1, i+1))
+			}
+		}
+	}
+	switch *format {
+	case "text", "csv", "json", "env":
+	default:
+		exitWithError(fmt.Errorf("unknown output format %q", *format))
+	}
+	if *best && *format != "text" {
+		exitWithError(fmt.Errorf("the best candidate is printed in text format only"))
+	}
+	if *stream {
+		if *format != "csv" {
+			exitWithError(fmt.Errorf("streaming is available in CSV format only"))
+		}
+		for _, name := range []string{"top", "best", "sort-drift", "minimize-parts", "cost", "check", "compare", "sweep", "histogram", "input", "serial-log", "report", "netlist", "escalate", "compare-series"} {
+			if set[name] {
+				exitWithError(fmt.Errorf("-%s cannot be used with -stream", name))
+			}
+		}
+	}
+	if *header && *format == "json" {
+		exitWithError(fmt.Errorf("a header cannot be written in JSON format"))
+	}
+	if *input != "" && *serialLog != "" {
+		exitWithError(fmt.Errorf("give either -input or -serial-log, not both"))
+	}
+	if *average < 0 || (*average > 0 && *input == "" && *serialLog == "") {
+		exitWithError(fmt.Errorf("-average needs a positive count of readings and -input or -serial-log to read them from"))
+	}
+	if (*input != "" || *serialLog != "") && *format != "text" {
+		exitWithError(fmt.Errorf("an input file is summarized in text format only"))
+	}
+	if *interactive && (*input != "" || *serialLog != "" || *format != "text") {
+		exitWithError(fmt.Errorf("interactive mode reads from stdin and prints text only"))
+	}
+	minExp, maxExp, err := parseDecades(*decades)
+	if err != nil {
+		exitWithError(err)
+	}
+	if *valuesFile != "" {
+		if set["series"] || set["decades"] {
+			exitWithError(fmt.Errorf("give either -values or -series and -decades, not both"))
+		}
+		values, err := readValuesFile(*valuesFile)
+		if err != nil {
+			exitWithError(err)
+		}
+		npp301.Rvalues = values
+	} else {
+		values, err := npp301.SeriesRange(*series, minExp, maxExp)
+		if err != nil {
+			exitWithError(err)
+		}
+		npp301.Rvalues = values
+	}
+	if *table && (*verbose || *format != "text") {
+		exitWithError(fmt.Errorf("-table lines up the text output, one line to a candidate, and cannot be used with -verbose or -format %s", *format))
+	}
+	if *roundDisplay < 0 || *roundDisplay > 17 {
+		exitWithError(fmt.Errorf("-round-display must be from 0 to 17 significant figures, got %d", *roundDisplay))
+	}
+	if *halfBridge && *bothLegs {
+		exitWithError(fmt.Errorf("a half-bridge is trimmed on leg 1-2 only and cannot be used with -both-legs"))
+	}
+	if *symmetric && (*single || *seriesPairs) {
+		exitWithError(fmt.Errorf("-symmetric tries matched pairs only and cannot be used with -single or -series-pairs"))
+	}
+	if *vexc == 0.0 {
+		exitWithError(fmt.Errorf("vexc must be a nonzero voltage, negative for a reversed bridge"))
+	}
+	if *adcInput < 0.0 || math.IsInf(*adcInput, 0) || math.IsNaN(*adcInput) {
+		exitWithError(fmt.Errorf("-adc-input must be a positive resistance, or 0 for no loading, got %v", *adcInput))
+	}
+	if *minTrim < 0.0 || *maxTrim < 0.0 || (*maxTrim != 0.0 && *minTrim > *maxTrim) {
+		exitWithError(fmt.Errorf("-min-trim and -max-trim must not be negative, nor the floor above the cap, got %v and %v", *minTrim, *maxTrim))
+	}
+	excitations, err := parseExcitations(*vexcAlso)
+	if err != nil {
+		exitWithError(err)
+	}
+	opts := npp301.SearchOptions{
+		Excitations:     excitations,
+		Single:          *single,
+		SeriesPairs:     *seriesPairs,
+		BothLegs:        *bothLegs,
+		Symmetric:       *symmetric,
+		HalfBridge:      *halfBridge,
+		WorstCaseTolPct: *worstCase,
+		JitterTolPct:    *jitter,
+		MaxTrim:         *maxTrim,
+		MinTrim:         *minTrim,
+		Target:          *target,
+	}
+	if !*quiet {
+		opts.Progress = newProgress()
+	}
+	if debug {
+		report := opts.Progress
+		opts.Progress = func(done, total int) {
+			debugf("row %d of %d of the search done", done, total)
+			if report != nil {
+				report(done, total)
+			}
+		}
+	}
+	if *valuesAB != "" {
+		if opts.ValuesAB, err = legValues(*valuesAB, minExp, maxExp); err != nil {
+			exitWithError(err)
+		}
+	}
+	if *valuesCD != "" {
+		if opts.ValuesCD, err = legValues(*valuesCD, minExp, maxExp); err != nil {
+			exitWithError(err)
+		}
+	}
+	var steps []seriesStep
+	if *compareSeriesFlag {
+		if *format != "text" {
+			exitWithError(fmt.Errorf("the series are compared in text format only"))
+		}
+		if *escalate != "" || set["series"] || *valuesFile != "" || *valuesAB != "" || *valuesCD != "" {
+			exitWithError(fmt.Errorf("-compare-series searches the standard series and cannot be used with -series, -values, -values-ab, -values-cd or -escalate"))
+		}
+		if steps, err = parseEscalation(compareSeries, minExp, maxExp, *minResistor, *maxResistor); err != nil {
+			exitWithError(err)
+		}
+		npp301.Rvalues = steps[0].values
+	}
+	if *escalate != "" {
+		if set["series"] || *valuesFile != "" {
+			exitWithError(fmt.Errorf("give either -escalate or -series or -values, not more than one"))
+		}
+		if *valuesAB != "" || *valuesCD != "" {
+			// The values given for a leg would stand in for each
+			// series in turn, so that the fallback changes nothing.
+			exitWithError(fmt.Errorf("-escalate cannot be used with -values-ab or -values-cd"))
+		}
+		if steps, err = parseEscalation(*escalate, minExp, maxExp, *minResistor, *maxResistor); err != nil {
+			exitWithError(err)
+		}
+		npp301.Rvalues = steps[0].values
+	}
+	if *minResistor != 0.0 || *maxResistor != 0.0 {
+		for _, values := range []*[]float64{&npp301.Rvalues, &opts.ValuesAB, &opts.ValuesCD} {
+			if *values == nil {
+				continue
+			}
+			if *values = npp301.ValuesInRange(*values, *minResistor, *maxResistor); len(*values) == 0 {
+				exitWithError(fmt.Errorf("no resistor values from %v to %v ohm", *minResistor, *maxResistor))
+			}
+		}
+	}
+	tf := textFormat{worstCasePct: *worstCase, powerLimit: *powerLimit, touchyFactor: *touchyFactor, verbose: *verbose,
+		currentLimit: *currentLimit, currentError: *currentError, figures: *roundDisplay,
+		span: *span, pMin: *pMin, pMax: *pMax,
+		outMin: *outMin, outMax: *outMax, zout: *zout,
+		adcVref: *adcVref, adcBits: *adcBits, gain: *gain, excitations: excitations, table: *table}
+	if tf.gain == 0.0 {
+		tf.gain = 1.0
+		if *span != 0.0 {
+			fs := npp301.NPP301{Vexc: *vexc}
+			tf.gain = (*outMax - *outMin) / fs.FullScale(*span, *pMin, *pMax)
+		}
+	}
+	if tf.refs, err = parseRefs(*schematicRef); err != nil {
+		exitWithError(err)
+	}
+	if *costFile != "" {
+		table, err := readCostFile(*costFile, *costDefault)
+		if err != nil {
+			exitWithError(err)
+		}
+		tf.cost = &table
+	}
+	// Drift is reported when either temperature coefficient is given.
+	if *tcArms != 0.0 || *tcBalance != 0.0 {
+		tf.drift = true
+		tf.deltaTMin, tf.deltaTMax = *tempMin-*tempCal, *tempMax-*tempCal
+	}
+	RL, err := parseLeads(*leads)
+	if err != nil {
+		exitWithError(err)
+	}
+	RS, err := parseSense(*sense)
+	if err != nil {
+		exitWithError(err)
+	}
+	unbalanceTol, err := parseArg("unbalanceTol", tolText)
+	if err != nil {
+		exitWithError(err)
+	}
+	if *tolPct != 0.0 {
+		if tolGiven {
+			exitWithError(fmt.Errorf("give unbalanceTol either as -tol or as -tol-pct, not both"))
+		}
+		if *span == 0.0 {
+			exitWithError(fmt.Errorf("-tol-pct needs the sensor sensitivity given by -span"))
+		}
+		fs := npp301.NPP301{Vexc: *vexc}
+		unbalanceTol = *tolPct / 100.0 * fs.FullScale(*span, *pMin, *pMax)
+	}
+	tf.unbalanceTol, tf.target = unbalanceTol, opts.Target
+	if *header {
+		values := fmt.Sprintf("series=%s decades=%s", *series, *decades)
+		if *valuesFile != "" {
+			values = "values=" + *valuesFile
+		}
+		command := append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...)
+		writeHeader(os.Stdout, time.Now(), command,
+			fmt.Sprintf("%s vexc=%v unbalanceTol=%v target=%v leads=%s", values, *vexc, unbalanceTol, *target, *leads))
+	}
+	if *input != "" || *serialLog != "" {
+		read, source, name := readSensorFile, "input", *input
+		if *serialLog != "" {
+			read, source, name = readSerialLogFile, "serial-log", *serialLog
+		}
+		sensors, err := read(name)
+		if err != nil {
+			exitWithError(err)
+		}
+		fmt.Printf("%s=%s unbalanceTol=%v", source, name, unbalanceTol)
+		if opts.Target != 0.0 {
+			fmt.Printf(" target=%v", opts.Target)
+		}
+		fmt.Println()
+		if *average > 0 {
+			sensors = averageReadings(sensors, *average)
+		}
+		common := npp301.NPP301{Vexc: *vexc, TCArms: *tcArms, TCBalance: *tcBalance,
+			RL1: RL[0], RL2: RL[1], RL3: RL[2], RL4: RL[3], RS2: RS[0], RS6: RS[1], RADC: *adcInput}
+		unsolved, failed := writeBatch(os.Stdout, tf, sensors, common, unbalanceTol, opts)
+		fmt.Println("Done.")
+		if failed > 0 {
+			warnf("%d of %d rows could not be read", failed, len(sensors))
+		}
+		if unsolved > 0 {
+			warnf("%d of %d sensors have no candidate solutions", unsolved, len(sensors))
+		}
+		switch {
+		case failed > 0:
+			os.Exit(1)
+		case unsolved > 0:
+			os.Exit(exitNoSolution)
+		}
+		return
+	}
+	if *interactive {
+		common := npp301.NPP301{Vexc: *vexc, TCArms: *tcArms, TCBalance: *tcBalance,
+			RL1: RL[0], RL2: RL[1], RL3: RL[2], RL4: RL[3], RS2: RS[0], RS6: RS[1], RADC: *adcInput}
+		if err := runInteractive(os.Stdin, os.Stdout, tf, common, unbalanceTol, opts); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+	// Set the measured resistance values from command-line parameters.
+	var R [4]float64
+	for i := range R {
+		var err error
+		R[i], err = parseResistance(fmt.Sprintf("R%d", i+1), armTexts[i])
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+	bridge, err := npp301.NewNPP301(R[0], R[1], R[2], R[3], *vexc)
+	if err != nil {
+		exitWithError(err)
+	}
+	npp := *bridge
+	npp.TCArms, npp.TCBalance = *tcArms, *tcBalance
+	npp.RL1, npp.RL2, npp.RL3, npp.RL4 = RL[0], RL[1], RL[2], RL[3]
+	npp.RS2, npp.RS6 = RS[0], RS[1]
+	npp.RADC = *adcInput
+	if err := npp.CheckCurrent(tf.currentLimit); err != nil {
+		if tf.currentError {
+			exitWithError(err)
+		}
+		warnf("%v", err)
+	}
+	warnConditioning("", npp)
+	if opts.HalfBridge && npp.AdjustsLeg34(opts.Target) {
+		warnf("v2 is above the reference v6 and RA/RB can only raise it; choose R3 and R4 to set the reference higher")
+		os.Exit(exitNoSolution)
+	}
+	text := *format == "text"
+	if text {
+		fmt.Printf("npp= %v unbalanceTol=%v\n", npp, unbalanceTol)
+		// fmt.Printf("Rvalues= %v\n", npp301.Rvalues)
+		// The initial unbalance is just v2-v6 with zero-value resistors applied.
+		offset := npp.NaturalOffset()
+		fmt.Printf("initial unbalance v2-v6= %v: %s\n", offset, unbalanceMeaning(offset, opts.Target, npp.AdjustsLeg34(opts.Target)))
+		if RAB, RCD := npp.IdealBalance(opts.Target); RAB != 0.0 {
+			fmt.Printf("ideal balance resistance RAB= %.3f ohm\n", RAB)
+		} else if RCD != 0.0 {
+			fmt.Printf("ideal balance resistance RCD= %.3f ohm\n", RCD)
+		}
+		if step := npp.Resolution(opts); step > 0.0 {
+			fmt.Printf("finest achievable step about %.1e V, against unbalanceTol=%v\n", step, unbalanceTol)
+		}
+	}
+	if *armSwap {
+		swaps := npp.ArmSwaps(opts.Target, npp301.Rvalues)
+		if len(swaps) == 0 {
+			warnf("no arm can be replaced to balance the bridge")
+			os.Exit(exitNoSolution)
+		}
+		for _, swap := range swaps {
+			fmt.Printf("replace R%d with %s (ideal %.2f ohm): v2mv6=%.1e mV/V=%.3f\n", swap.Arm,
+				formatOhms(swap.Value), swap.Ideal, swap.Bridge.V2mV6, swap.Bridge.SensitivityMvPerV())
+		}
+		fmt.Println("Done.")
+		return
+	}
+	if *trimpot != 0.0 {
+		fraction, R, err := npp.Trimpot(*trimpot, opts.Target)
+		if err != nil {
+			exitWithError(err)
+		}
+		position, other := tf.ref("RA"), tf.ref("RB")
+		if RAB, _ := npp.IdealBalance(opts.Target); RAB == 0.0 {
+			position, other = tf.ref("RC"), tf.ref("RD")
+		}
+		fmt.Printf("Fit a %s trimpot, wired as a variable resistor, at %s and leave %s open;\n",
+			formatOhms(*trimpot), position, other)
+		fmt.Printf("set the wiper to %.3f of its travel, %.2f ohm in circuit.\n", fraction, R)
+		fmt.Println("Done.")
+		return
+	}
+	if *compare != "" {
+		sets := strings.Split(*compare, "/")
+		if len(sets) != 2 {
+			exitWithError(fmt.Errorf("expected two sets of balance resistors separated by /, got %q", *compare))
+		}
+		var pair [2]npp301.NPP301
+		for i, text := range sets {
+			R, err := parseCheck(text)
+			if err != nil {
+				exitWithError(err)
+			}
+			pair[i] = npp
+			pair[i].RA, pair[i].RB, pair[i].RC, pair[i].RD = R[0], R[1], R[2], R[3]
+			pair[i].ComputeUnbalance()
+		}
+		tolPct := *worstCase
+		if tolPct == 0.0 {
+			tolPct = *mcTol
+		}
+		writeComparison(os.Stdout, pair[0], pair[1], tolPct, *tempMin-*tempCal, *tempMax-*tempCal)
+		fmt.Println("Done.")
+		return
+	}
+	if *sweep != "" {
+		R, err := parseCheck(*hold)
+		if err != nil {
+			exitWithError(err)
+		}
+		s := npp
+		s.RA, s.RB, s.RC, s.RD = R[0], R[1], R[2], R[3]
+		values := npp301.Rvalues
+		switch {
+		case strings.HasPrefix(*sweep, "RA") || *sweep == "RB":
+			if opts.ValuesAB != nil {
+				values = opts.ValuesAB
+			}
+		case opts.ValuesCD != nil:
+			values = opts.ValuesCD
+		}
+		if err := writeSweep(os.Stdout, tf, s, *sweep, values, opts.Target); err != nil {
+			exitWithError(err)
+		}
+		fmt.Println("Done.")
+		return
+	}
+	if *check != "" {
+		R, err := parseCheck(*check)
+		if err != nil {
+			exitWithError(err)
+		}
+		c := npp
+		c.RA, c.RB, c.RC, c.RD = R[0], R[1], R[2], R[3]
+		c.ComputeUnbalance()
+		pass := opts.Passes(&c, unbalanceTol)
+		switch *format {
+		case "text":
+			writeCheck(os.Stdout, tf, c, pass, unbalanceTol)
+			fmt.Println("Done.")
+		case "csv":
+			err = writeCSV(os.Stdout, []npp301.NPP301{c})
+		case "json":
+			err = writeJSON(os.Stdout, npp, unbalanceTol, opts.Target, []npp301.NPP301{c})
+		case "env":
+			writeEnv(os.Stdout, c)
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+		if !pass {
+			os.Exit(exitNoSolution)
+		}
+		return
+	}
+	if *compareSeriesFlag {
+		writeSeriesComparison(os.Stdout, tf, npp, unbalanceTol, opts, steps)
+		fmt.Println("Done.")
+		return
+	}
+	if *histogram > 0 {
+		// With an infinite tolerance, every combination tried is kept,
+		// the search going on even when the bare bridge is within it.
+		// A finite one narrows the histogram to the offsets of interest.
+		opts.Exhaustive = true
+		tol := math.Inf(1)
+		if *histMax > 0.0 {
+			tol = *histMax
+		}
+		all := npp.SolveWith(tol, opts)
+		writeHistogram(os.Stdout, all, *histogram)
+		fmt.Println("Done.")
+		return
+	}
+	if *stream {
+		out := newCSVStream(os.Stdout)
+		opts.Emit = out.emit
+		npp.Search(unbalanceTol, opts)
+		if err := out.cw.Error(); err != nil {
+			exitWithError(err)
+		}
+		if out.n == 0 {
+			warnf("no candidate solutions made the cut")
+			os.Exit(exitNoSolution)
+		}
+		return
+	}
+	debugf("searching %d values with unbalanceTol=%v, single=%v series-pairs=%v both-legs=%v",
+		len(npp301.Rvalues), unbalanceTol, opts.Single, opts.SeriesPairs, opts.BothLegs)
+	var result npp301.SearchResult
+	note := ""
+	if steps != nil {
+		var used int
+		result, used = searchEscalating(npp, unbalanceTol, opts, steps)
+		note = escalationNote(steps, used, len(result.Candidates) > 0)
+	} else {
+		result = npp.Search(unbalanceTol, opts)
+	}
+	debugf("tried %d combinations, %d passed", result.Tried, len(result.Candidates))
+	if note != "" {
+		if text {
+			fmt.Println(note)
+		} else {
+			log.Print("note: ", note)
+		}
+	}
+	candidates := result.Candidates
+	if *sortDrift {
+		npp301.SortByDrift(candidates, *tempMin-*tempCal, *tempMax-*tempCal)
+	}
+	if *minimizeParts {
+		npp301.SortByParts(candidates)
+	}
+	if tf.cost != nil {
+		npp301.SortByCost(candidates, *tf.cost)
+	}
+	shown := candidates
+	if *top > 0 && *top < len(candidates) {
+		shown = candidates[:*top]
+	}
+	if *report != "" {
+		if err := writeReportFile(*report, tf, npp, unbalanceTol, result); err != nil {
+			exitWithError(err)
+		}
+	}
+	if *netlist != "" {
+		if err := writeNetlistFile(*netlist, result); err != nil {
+			exitWithError(err)
+		}
+	}
+	switch {
+	case result.AlreadyBalanced && *format == "text":
+		fmt.Println("Bridge already balanced, no resistors needed.")
+		fmt.Println("Done.")
+	case *best:
+		writeBest(os.Stdout, tf, result, opts.Target)
+		fmt.Println("Done.")
+	case *format == "text":
+		writeText(os.Stdout, tf, shown, len(candidates), *top > 0)
+		writeClosest(os.Stdout, tf, result, opts.Target, "")
+		if len(candidates) > 0 {
+			writeGain(os.Stdout, tf, candidates[0])
+		}
+		if *mcSamples > 0 && len(candidates) > 0 {
+			source := *seed
+			if source == 0 {
+				source = time.Now().UnixNano()
+			}
+			rng := rand.New(rand.NewSource(source))
+			best := candidates[0]
+			stats := best.MonteCarlo(*mcTol, *mcSamples, *mcNormal, rng)
+			writeMonteCarlo(os.Stdout, tf, best, stats, *mcTol, *mcNormal, source)
+		}
+		if !*noSummary {
+			writeSummary(os.Stdout, result, opts.Target)
+		}
+		fmt.Println("Done.")
+	case *format == "csv":
+		if err := writeCSV(os.Stdout, shown); err != nil {
+			exitWithError(err)
+		}
+		warnCandidates(shown, tf)
+	case *format == "json":
+		if err := writeJSON(os.Stdout, npp, unbalanceTol, opts.Target, shown); err != nil {
+			exitWithError(err)
+		}
+		warnCandidates(shown, tf)
+	case *format == "env":
+		if len(candidates) > 0 {
+			writeEnv(os.Stdout, candidates[0])
+			warnCandidates(candidates[:1], tf)
+		}
+	}
+	if len(candidates) == 0 {
+		warnf("no candidate solutions made the cut")
+		os.Exit(exitNoSolution)
+	}
+}
@@ -0,0 +1,114 @@
+// netlist.go
+// A SPICE netlist of the balanced bridge, for simulation in LTspice.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// writeNetlist prints a SPICE netlist of the bridge c, with its balance
+// resistors, excited by a voltage source from node exc to ground.
+// The output pins are the nodes 2 and 6, as on the sensor.  Sense lines,
+// when given, run from the pins to the nodes s2 and s6, and the ADC
+// input, when given, loads the end of each line to ground, so that the
+// operating point gives, across the nodes read, the v2-v6 predicted.
+// Lead resistances are wired in series with their arms, when given.
+func writeNetlist(w io.Writer, when time.Time, c npp301.NPP301) {
+	c.ComputeUnbalance()
+	read2, read6 := readNode("2", c.RS2), readNode("6", c.RS6)
+	fmt.Fprintln(w, "* NPP-301 bridge with balance resistors")
+	fmt.Fprintf(w, "* written by balance_npp301 on %s\n", when.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "* predicted v(%s)-v(%s) = %.6e V\n", read2, read6, c.V2mV6)
+	fmt.Fprintf(w, "Vexc exc 0 %v\n", c.Excitation())
+	writeNetlistLeg(w, "2", "1", "2", "12", c.R1, c.RL1, c.R2, c.RL2, "RA", "RB", c.RA, c.RA2, c.RB)
+	writeNetlistLeg(w, "6", "3", "4", "34", c.R3, c.RL3, c.R4, c.RL4, "RC", "RD", c.RC, c.RC2, c.RD)
+	writeReadout(w, "2", c.RS2, c.RADC)
+	writeReadout(w, "6", c.RS6, c.RADC)
+	fmt.Fprintln(w, ".op")
+	fmt.Fprintln(w, ".end")
+}
+
+// writeNetlistLeg prints one leg of the bridge: the top arm from exc
+// to the output pin, the bottom arm, and the parallel pair of balance
+// resistors to ground.  A leg without balance resistors is jumpered
+// to ground, SPICE not allowing a resistor of zero ohms.
+func writeNetlistLeg(w io.Writer, pin, top, bottom, leg string, Rtop, RLtop, Rbottom, RLbottom float64,
+	nameA, nameB string, Ra, Ra2, Rb float64) {
+	base := "bal" + leg
+	if Ra == 0.0 && Rb == 0.0 {
+		base = "0"
+	}
+	writeArm(w, top, "exc", pin, Rtop, RLtop)
+	writeArm(w, bottom, pin, base, Rbottom, RLbottom)
+	if Ra != 0.0 {
+		if Ra2 != 0.0 {
+			mid := strings.ToLower(nameA) + "2"
+			fmt.Fprintf(w, "%s %s %s %v\n", nameA, base, mid, Ra)
+			fmt.Fprintf(w, "%s2 %s 0 %v\n", nameA, mid, Ra2)
+		} else {
+			fmt.Fprintf(w, "%s %s 0 %v\n", nameA, base, Ra)
+		}
+	}
+	if Rb != 0.0 {
+		fmt.Fprintf(w, "%s %s 0 %v\n", nameB, base, Rb)
+	}
+}
+
+// writeArm prints the arm R<n> from node a to node b,
+// with its lead resistance RL<n> in series when it is nonzero.
+func writeArm(w io.Writer, n, a, b string, R, RL float64) {
+	if RL == 0.0 {
+		fmt.Fprintf(w, "R%s %s %s %v\n", n, a, b, R)
+		return
+	}
+	lead := "lead" + n
+	fmt.Fprintf(w, "RL%s %s %s %v\n", n, a, lead, RL)
+	fmt.Fprintf(w, "R%s %s %s %v\n", n, lead, b, R)
+}
+
+// readNode returns the node at which the output pin is read:
+// the far end of its sense line, if it has one, or else the pin.
+func readNode(pin string, RS float64) string {
+	if RS == 0.0 {
+		return pin
+	}
+	return "s" + pin
+}
+
+// writeReadout prints the sense line RS<pin>, from the output pin to
+// the node read, and the input resistance RADC<pin> of the ADC from
+// that node to ground, each when it is nonzero.
+func writeReadout(w io.Writer, pin string, RS, RADC float64) {
+	node := readNode(pin, RS)
+	if RS != 0.0 {
+		fmt.Fprintf(w, "RS%s %s %s %v\n", pin, pin, node, RS)
+	}
+	if RADC != 0.0 {
+		fmt.Fprintf(w, "RADC%s %s 0 %v\n", pin, node, RADC)
+	}
+}
+
+// writeNetlistFile writes a netlist of the best candidate, or of the
+// closest combination tried if none made the cut, to the named file.
+func writeNetlistFile(name string, result npp301.SearchResult) error {
+	if result.Tried == 0 {
+		return fmt.Errorf("no combinations of balance resistors were tried for the netlist")
+	}
+	c := result.Closest
+	if len(result.Candidates) > 0 {
+		c = result.Candidates[0]
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	writeNetlist(f, time.Now(), c)
+	return f.Close()
+}
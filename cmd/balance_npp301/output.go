@@ -0,0 +1,547 @@
+// output.go
+// Formatting of the candidate solutions for the console and for other programs.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// textFormat holds the choices for the human-readable output.
+type textFormat struct {
+	// worstCasePct, when nonzero, is the balance resistor tolerance
+	// over which the worst-case output is reported.
+	worstCasePct float64
+	// drift reports the worst output over the temperature range
+	// deltaTMin to deltaTMax degC from calibration.
+	drift                bool
+	deltaTMin, deltaTMax float64
+	// powerLimit, when nonzero, is the rating in watts above which
+	// a balance resistor is flagged on stderr.
+	powerLimit float64
+	// touchyFactor, when nonzero, flags on stderr a candidate whose
+	// balance resistance is within this factor of its arm.
+	touchyFactor float64
+	// currentLimit, when nonzero, is the bridge current in amps above
+	// which a warning is given, or an error if currentError is set.
+	currentLimit float64
+	currentError bool
+	// verbose adds a line with the full state of the bridge.
+	verbose bool
+	// span, when nonzero, is the sensitivity in mV/V per unit pressure
+	// for which the output is reported over the range pMin to pMax.
+	span       float64
+	pMin, pMax float64
+	// outMin and outMax are the amplifier output range for which
+	// the gain is recommended, when span is given.
+	outMin, outMax float64
+	// zout reports the source resistance at the output pins.
+	zout bool
+	// adcVref, when nonzero, with adcBits and the amplifier gain,
+	// gives the offset in codes of the ADC.
+	adcVref float64
+	adcBits int
+	gain    float64
+	// unbalanceTol and target, when the tolerance is set,
+	// grade each candidate by how far inside the tolerance it sits.
+	unbalanceTol, target float64
+	// cost, when set, is the price list for the cost of each candidate.
+	cost *npp301.CostTable
+	// figures, when nonzero, is the number of significant figures
+	// for the outputs and balance resistances of each candidate,
+	// in place of the fixed rounding.
+	figures int
+	// excitations are the other excitation voltages at which
+	// the output of each candidate is also shown.
+	excitations []float64
+	// table lines up the candidates listed in columns.
+	table bool
+	// refs gives the schematic designators, by balance resistor name,
+	// to use in place of the names RA, RB, RC and RD.
+	refs map[string]string
+}
+
+// num formats x with format, or to the significant figures asked for.
+func (tf textFormat) num(format string, x float64) string {
+	if tf.figures > 0 {
+		return strconv.FormatFloat(x, 'g', tf.figures, 64)
+	}
+	return fmt.Sprintf(format, x)
+}
+
+// ref returns the designator for the balance resistor of this name.
+func (tf textFormat) ref(name string) string {
+	if ref, ok := tf.refs[name]; ok {
+		return ref
+	}
+	return name
+}
+
+// writeHeader prints a record of the run, as # comment lines,
+// so that a saved output describes how it was made.
+func writeHeader(w io.Writer, when time.Time, args []string, settings string) {
+	fmt.Fprintf(w, "# balance_npp301 run at %s\n", when.Format(time.RFC3339))
+	fmt.Fprintf(w, "# command: %s\n", strings.Join(args, " "))
+	fmt.Fprintf(w, "# %s\n", settings)
+}
+
+// writeText prints the candidates in human-readable form.
+// total is the number of candidates found, of which shown may be the best few.
+func writeText(w io.Writer, tf textFormat, shown []npp301.NPP301, total int, summary bool) {
+	if total == 0 {
+		return
+	}
+	if summary {
+		fmt.Fprintf(w, "showing %d of %d candidates\n", len(shown), total)
+	}
+	if tf.table {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, c := range shown {
+			fmt.Fprintln(tw, strings.Join(tf.fields(c), "\t"))
+		}
+		tw.Flush()
+	} else {
+		for _, c := range shown {
+			fmt.Fprintln(w, tf.candidate(c))
+		}
+	}
+	warnCandidates(shown, tf)
+}
+
+// candidate gives the one-line human-readable form of a candidate.
+func (tf textFormat) candidate(c npp301.NPP301) string {
+	line := strings.Join(tf.fields(c), " ")
+	if tf.verbose {
+		line += fmt.Sprintf("\n    v2=%.6f v6=%.6f i12=%.6e i34=%.6e RAB=%.4f RCD=%.4f",
+			c.V2, c.V6, c.I12, c.I34,
+			c.RABValue(), c.RCDValue())
+		d := c.Sensitivities()
+		line += fmt.Sprintf("\n    dv2mv6/dR in V/ohm: RA=%.3e RB=%.3e RC=%.3e RD=%.3e", d.RA, d.RB, d.RC, d.RD)
+		if c.RA2 != 0.0 || c.RC2 != 0.0 {
+			line += fmt.Sprintf(" RA2=%.3e RC2=%.3e", d.RA2, d.RC2)
+		}
+	}
+	return line
+}
+
+// fields gives the parts of the line for a candidate, in order,
+// to be joined by spaces or lined up in the columns of a table.
+func (tf textFormat) fields(c npp301.NPP301) []string {
+	fields := []string{
+		tf.ref("RA") + "=" + seriesText(c.RA, c.RA2), tf.ref("RB") + "=" + formatOhms(c.RB),
+		tf.ref("RC") + "=" + seriesText(c.RC, c.RC2), tf.ref("RD") + "=" + formatOhms(c.RD),
+		"v2mv6=" + tf.num("%.1e", c.V2mV6), "mV/V=" + tf.num("%.3f", c.SensitivityMvPerV()),
+		"vcm=" + tf.num("%.4f", c.CommonMode()),
+		fmt.Sprintf("(RAB=%s RCD=%s)", tf.num("%.1f", c.RABValue()), tf.num("%.1f", c.RCDValue())),
+	}
+	if tf.unbalanceTol > 0.0 {
+		fields = append(fields, "grade="+c.Grade(tf.target, tf.unbalanceTol))
+	}
+	if tf.worstCasePct != 0.0 {
+		fields = append(fields, fmt.Sprintf("worst=%.1e", c.WorstCase(tf.worstCasePct)))
+	}
+	if tf.drift {
+		fields = append(fields, fmt.Sprintf("drift=%.1e", c.Drift(tf.deltaTMin, tf.deltaTMax)))
+	}
+	if tf.adcVref != 0.0 {
+		fields = append(fields, fmt.Sprintf("offset=%.1f LSB", c.OffsetLSB(tf.gain, tf.adcVref, tf.adcBits)))
+	}
+	if tf.zout {
+		z2, z6 := c.OutputImpedance()
+		fields = append(fields, fmt.Sprintf("zout=%.1f/%.1f", z2, z6))
+	}
+	if tf.span != 0.0 {
+		vMin, vMax := c.Span(tf.span, tf.pMin, tf.pMax)
+		fields = append(fields, fmt.Sprintf("span=%.4f..%.4f V", vMin, vMax))
+		fields = append(fields, fmt.Sprintf("offset=%.1f ppm FS", c.OffsetPPMFS(tf.span, tf.pMin, tf.pMax)))
+		fields = append(fields, fmt.Sprintf("span-change=%+.2f%%", c.SensitivityChangePct()))
+	}
+	if tf.cost != nil {
+		fields = append(fields, fmt.Sprintf("cost=%.3f", c.Cost(*tf.cost)))
+	}
+	for _, vexc := range tf.excitations {
+		at := c.WithExcitation(vexc)
+		fields = append(fields, fmt.Sprintf("v2mv6@%gV=%s", vexc, tf.num("%.1e", at.V2mV6)))
+	}
+	if c.BothLegs() {
+		fields = append(fields, "both legs")
+	}
+	return fields
+}
+
+// writeClosest reports, when no candidate made the cut,
+// the closest combination tried and how far it is from the target,
+// so that it is clear how much the tolerance would need to be loosened.
+func writeClosest(w io.Writer, tf textFormat, result npp301.SearchResult, target float64, indent string) {
+	if len(result.Candidates) > 0 || result.Tried == 0 {
+		return
+	}
+	c := result.Closest
+	fmt.Fprintf(w, "%sClosest achievable: %s\n", indent, tf.candidate(c))
+	fmt.Fprintf(w, "%sresidual %s V, so unbalanceTol would need to exceed %s\n",
+		indent, tf.num("%.3e", c.V2mV6-target), tf.num("%.3e", math.Abs(c.V2mV6-target)))
+}
+
+// writeSummary prints the counts of the search and the spread of
+// the offsets of the candidates that passed.
+func writeSummary(w io.Writer, result npp301.SearchResult, target float64) {
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  combinations tried: %d\n", result.Tried)
+	fmt.Fprintf(w, "  passed tolerance: %d\n", len(result.Candidates))
+	fmt.Fprintf(w, "  pruned: %d\n", result.Tried-len(result.Candidates))
+	if len(result.Candidates) == 0 {
+		fmt.Fprintf(w, "  closest offset: %.3e V\n", result.Closest.V2mV6)
+		return
+	}
+	tightest, loosest := result.Candidates[0].V2mV6, result.Candidates[0].V2mV6
+	for _, c := range result.Candidates {
+		if math.Abs(c.V2mV6-target) < math.Abs(tightest-target) {
+			tightest = c.V2mV6
+		}
+		if math.Abs(c.V2mV6-target) > math.Abs(loosest-target) {
+			loosest = c.V2mV6
+		}
+	}
+	fmt.Fprintf(w, "  tightest offset: %.3e V\n", tightest)
+	fmt.Fprintf(w, "  loosest offset: %.3e V\n", loosest)
+}
+
+// writeBest prints assembly instructions for the single best candidate.
+// With no candidate within tolerance, it gives those for the closest
+// combination tried, so that it is clear how near we can get.
+func writeBest(w io.Writer, tf textFormat, result npp301.SearchResult, target float64) {
+	switch {
+	case len(result.Candidates) > 0:
+		fmt.Fprintln(w, "Best candidate:", tf.candidate(result.Candidates[0]))
+	case result.Tried > 0:
+		fmt.Fprintln(w, "No candidate made the cut; the closest combination tried is")
+		fmt.Fprintln(w, "Closest:", tf.candidate(result.Closest))
+	default:
+		fmt.Fprintln(w, "No combinations of balance resistors were tried.")
+		return
+	}
+	best := result.Closest
+	if len(result.Candidates) > 0 {
+		best = result.Candidates[0]
+	}
+	fmt.Fprintln(w, "Fit the balance resistors as follows:")
+	writeLeg(w, tf, "1-2", "RA", "RB", best.RA, best.RA2, best.RB)
+	writeLeg(w, tf, "3-4", "RC", "RD", best.RC, best.RC2, best.RD)
+	fmt.Fprintf(w, "Expected residual v2-v6= %s V, %s mV/V", tf.num("%.3e", best.V2mV6), tf.num("%.4f", best.SensitivityMvPerV()))
+	if target != 0.0 {
+		fmt.Fprintf(w, ", %.3e V from the target", best.V2mV6-target)
+	}
+	fmt.Fprintln(w)
+	if tf.adcVref != 0.0 {
+		fmt.Fprintf(w, "residual offset = %.0f LSB of a %d-bit ADC on %v V, after a gain of %.1f\n",
+			best.OffsetLSB(tf.gain, tf.adcVref, tf.adcBits), tf.adcBits, tf.adcVref, tf.gain)
+	}
+	writeGain(w, tf, best)
+}
+
+// writeGain recommends the amplifier gain for a candidate,
+// when the sensor span is given.
+func writeGain(w io.Writer, tf textFormat, c npp301.NPP301) {
+	if tf.span == 0.0 {
+		return
+	}
+	vMin, _ := c.Span(tf.span, tf.pMin, tf.pMax)
+	gain, offset := c.Gain(tf.span, tf.pMin, tf.pMax, tf.outMin, tf.outMax)
+	fmt.Fprintf(w, "For %.4g V to %.4g V out, use a gain of %.1f: offset after gain= %.4f V, reference shift= %.4f V\n",
+		tf.outMin, tf.outMax, gain, offset, tf.outMin-gain*vMin)
+}
+
+// writeCheck reports the output with a given set of balance resistors,
+// such as those on a board already built.
+func writeCheck(w io.Writer, tf textFormat, c npp301.NPP301, pass bool, unbalanceTol float64) {
+	fmt.Fprintln(w, "Checked:", tf.candidate(c))
+	if pass {
+		fmt.Fprintf(w, "v2-v6= %.3e V is within tolerance %v\n", c.V2mV6, unbalanceTol)
+	} else {
+		fmt.Fprintf(w, "v2-v6= %.3e V does NOT make the cut for tolerance %v\n", c.V2mV6, unbalanceTol)
+	}
+}
+
+// writeComparison prints two sets of balance resistors side by side,
+// with the figures that decide between them: the offset, the worst case
+// over tolPct balance resistor tolerance, the drift over deltaTMin to
+// deltaTMax degC, the largest balance resistor dissipation and the
+// source resistance at the output pins.
+func writeComparison(w io.Writer, a, b npp301.NPP301, tolPct, deltaTMin, deltaTMax float64) {
+	line := func(name, textA, textB string) {
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("%-22s %-18s %s", name, textA, textB), " "))
+	}
+	row := func(name string, format string, f func(c npp301.NPP301) float64) {
+		line(name, fmt.Sprintf(format, f(a)), fmt.Sprintf(format, f(b)))
+	}
+	line("", "A", "B")
+	line("RA RB", seriesText(a.RA, a.RA2)+" "+formatOhms(a.RB), seriesText(b.RA, b.RA2)+" "+formatOhms(b.RB))
+	line("RC RD", seriesText(a.RC, a.RC2)+" "+formatOhms(a.RD), seriesText(b.RC, b.RC2)+" "+formatOhms(b.RD))
+	row("v2-v6 (V)", "%.3e", func(c npp301.NPP301) float64 { return c.V2mV6 })
+	row(fmt.Sprintf("worst at %.3g%% (V)", tolPct), "%.3e", func(c npp301.NPP301) float64 { return c.WorstCase(tolPct) })
+	row("drift (V)", "%.3e", func(c npp301.NPP301) float64 { return c.Drift(deltaTMin, deltaTMax) })
+	row("balance power (W)", "%.3e", func(c npp301.NPP301) float64 { return c.Dissipation().MaxBalance() })
+	row("zout pin 2 (ohm)", "%.1f", func(c npp301.NPP301) float64 { z2, _ := c.OutputImpedance(); return z2 })
+	row("zout pin 6 (ohm)", "%.1f", func(c npp301.NPP301) float64 { _, z6 := c.OutputImpedance(); return z6 })
+}
+
+// writeLeg prints the fitting of the parallel pair at the bottom of one leg.
+// The positions are named nameA and nameB, and nameA2 is the second
+// resistor in series with the first, labelled only when it has a designator.
+func writeLeg(w io.Writer, tf textFormat, leg, nameA, nameB string, Ra, Ra2, Rb float64) {
+	refA, refB := tf.ref(nameA), tf.ref(nameB)
+	if Ra == 0.0 && Rb == 0.0 {
+		fmt.Fprintf(w, "  leg %s: jumper the balance position, %s and %s not fitted\n", leg, refA, refB)
+		return
+	}
+	if Ra == Rb && Ra2 == 0.0 {
+		// A matched pair is reported with the resistance it makes.
+		fmt.Fprintf(w, "  leg %s: %s = %s = %s, %.2f ohm in parallel\n", leg, refA, refB,
+			formatOhms(Ra), npp301.ParallelR(Ra, Rb))
+		return
+	}
+	fmt.Fprintf(w, "  leg %s: %s %s, %s %s\n", leg, refA, placement(Ra, Ra2, tf.refs[nameA+"2"]), refB, placement(Rb, 0.0, ""))
+}
+
+// placement describes what to fit at one balance resistor position,
+// R2 being in series, with the designator ref2 if it has one.
+func placement(R, R2 float64, ref2 string) string {
+	switch {
+	case R == 0.0:
+		return "not fitted"
+	case R2 == 0.0:
+		return "= " + formatOhms(R)
+	case ref2 != "":
+		return fmt.Sprintf("= %s in series with %s = %s", formatOhms(R), ref2, formatOhms(R2))
+	}
+	return fmt.Sprintf("= %s in series with %s", formatOhms(R), formatOhms(R2))
+}
+
+// unbalanceMeaning explains the sign of the initial unbalance
+// and so which pair of balance resistors the search adjusts
+// to bring the output to the target, as given by leg34.
+func unbalanceMeaning(v2mv6, target float64, leg34 bool) string {
+	leg := "leg 1-2 (RA/RB)"
+	if leg34 {
+		leg = "leg 3-4 (RC/RD)"
+	}
+	if target != 0.0 {
+		switch {
+		case v2mv6 > target:
+			return "v2-v6 is above the target, adding resistance to " + leg
+		case v2mv6 < target:
+			return "v2-v6 is below the target, adding resistance to " + leg
+		}
+		return "v2-v6 is already at the target"
+	}
+	switch {
+	case v2mv6 > 0.0:
+		return "v2 is higher, adding resistance to " + leg
+	case v2mv6 < 0.0:
+		return "v6 is higher, adding resistance to " + leg
+	}
+	return "v2 and v6 are equal, the bridge is balanced"
+}
+
+// seriesText shows a balance resistor R, with its series partner R2 if fitted.
+func seriesText(R, R2 float64) string {
+	if R2 == 0.0 {
+		return formatOhms(R)
+	}
+	return formatOhms(R) + "+" + formatOhms(R2)
+}
+
+// formatOhms writes a resistance as it is marked on the reel,
+// with the multiplier standing in for the decimal point,
+// so 4.7 ohm is 4R7, 2200 ohm is 2k2, 1 Mohm is 1M and 0.01 ohm is R01.
+// A resistor that is not fitted is shown as 0.
+func formatOhms(R float64) string {
+	if R == 0.0 {
+		return "0"
+	}
+	scale, suffix := 1.0, "R"
+	switch {
+	case R >= 1.0e6:
+		scale, suffix = 1.0e6, "M"
+	case R >= 1.0e3:
+		scale, suffix = 1.0e3, "k"
+	}
+	// Rounded to six figures but written out in full, as 'g' alone
+	// would give an exponent for a value well below 1 ohm.
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(R/scale, 'g', 6, 64), 64)
+	text := strconv.FormatFloat(rounded, 'f', -1, 64)
+	text = strings.TrimPrefix(text, "0")
+	if strings.Contains(text, ".") {
+		return strings.Replace(text, ".", suffix, 1)
+	}
+	return text + suffix
+}
+
+// writeMonteCarlo reports the spread of the output of a candidate
+// with toleranced balance resistors, and the seed of the samples,
+// so that a run seeded from the time can be repeated with -seed.
+func writeMonteCarlo(w io.Writer, tf textFormat, c npp301.NPP301, stats npp301.Stats, tolPct float64, normal bool, seed int64) {
+	dist := "uniform"
+	if normal {
+		dist = "normal"
+	}
+	fmt.Fprintf(w, "Monte Carlo of %s\n", tf.candidate(c))
+	fmt.Fprintf(w, "  %d samples, %s within %.2f%%, seed %d: mean v2mv6=%.3e stddev=%.3e\n",
+		stats.N, dist, tolPct, seed, stats.Mean, stats.StdDev)
+}
+
+// formatFloat writes a value at full precision for machine consumption.
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// writeEnv prints the balance resistors of a candidate, in ohms with 0
+// for not fitted, and its output as KEY=VALUE lines for a shell to eval.
+func writeEnv(w io.Writer, c npp301.NPP301) {
+	for _, kv := range []struct {
+		key   string
+		value float64
+	}{
+		{"RA", c.RA}, {"RB", c.RB}, {"RC", c.RC}, {"RD", c.RD}, {"RA2", c.RA2}, {"RC2", c.RC2},
+		{"RAB", c.RABValue()}, {"RCD", c.RCDValue()}, {"V2MV6", c.V2mV6},
+	} {
+		fmt.Fprintf(w, "%s=%s\n", kv.key, formatFloat(kv.value))
+	}
+}
+
+// csvHeader is the header row of the CSV format.
+var csvHeader = []string{"RA", "RB", "RC", "RD", "v2mv6", "RAB", "RCD", "mVperV", "vcm", "RA2", "RC2"}
+
+// csvRow gives the CSV fields of a candidate.
+func csvRow(c npp301.NPP301) []string {
+	return []string{
+		formatFloat(c.RA), formatFloat(c.RB), formatFloat(c.RC), formatFloat(c.RD),
+		formatFloat(c.V2mV6),
+		formatFloat(c.RABValue()), formatFloat(c.RCDValue()),
+		formatFloat(c.SensitivityMvPerV()), formatFloat(c.CommonMode()),
+		formatFloat(c.RA2), formatFloat(c.RC2),
+	}
+}
+
+// writeCSV writes a header row and then one row per candidate.
+func writeCSV(w io.Writer, candidates []npp301.NPP301) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, c := range candidates {
+		cw.Write(csvRow(c))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvStream writes candidates as they are found by the search.
+type csvStream struct {
+	cw *csv.Writer
+	n  int
+}
+
+// newCSVStream writes the header row and returns the stream.
+func newCSVStream(w io.Writer) *csvStream {
+	s := &csvStream{cw: csv.NewWriter(w)}
+	s.cw.Write(csvHeader)
+	s.cw.Flush()
+	return s
+}
+
+// emit writes one candidate row immediately.
+func (s *csvStream) emit(c npp301.NPP301) {
+	s.cw.Write(csvRow(c))
+	s.cw.Flush()
+	s.n++
+}
+
+// The JSON output is a single object, laid out as resultJSON.
+// Its fields are kept stable for scripts, new ones being added
+// without changing the meaning of the old, and schemaVersion is
+// raised should a field ever change.
+const schemaVersion = 1
+
+// legJSON is the balance position at the bottom of one leg of a candidate,
+// so that the leg need not be inferred from which resistors are fitted.
+// A and B are the parallel pair, in ohms, named by positions, with A2 in
+// series with A; zero is not fitted.  Parallel is the resistance
+// they make, zero for a jumpered position.
+type legJSON struct {
+	Leg       string    `json:"leg"`
+	Positions [2]string `json:"positions"`
+	A         float64   `json:"a"`
+	A2        float64   `json:"a2"`
+	B         float64   `json:"b"`
+	Parallel  float64   `json:"parallel"`
+}
+
+// candidateJSON adds the parallel combinations to a candidate,
+// with the balance positions of both legs and the offset it leaves.
+type candidateJSON struct {
+	npp301.NPP301
+	RAB    float64    `json:"RAB"`
+	RCD    float64    `json:"RCD"`
+	MVperV float64    `json:"mVperV"`
+	Vcm    float64    `json:"vcm"`
+	Grade  string     `json:"grade"`
+	Legs   [2]legJSON `json:"legs"`
+	Offset float64    `json:"offset"`
+}
+
+// resultJSON is the single object written in JSON format.
+// Excitation is in volts, and UnbalanceTol and Target are the
+// tolerance and output, in volts, that the search was made for.
+type resultJSON struct {
+	SchemaVersion    int             `json:"schemaVersion"`
+	Bridge           npp301.NPP301   `json:"bridge"`
+	Excitation       float64         `json:"excitation"`
+	UnbalanceTol     float64         `json:"unbalanceTol"`
+	Target           float64         `json:"target"`
+	InitialUnbalance float64         `json:"initialUnbalance"`
+	Candidates       []candidateJSON `json:"candidates"`
+}
+
+// writeJSON writes the bridge, its initial unbalance and the candidates
+// as a single JSON object.
+func writeJSON(w io.Writer, bridge npp301.NPP301, unbalanceTol, target float64, candidates []npp301.NPP301) error {
+	bridge.ComputeUnbalance()
+	result := resultJSON{
+		SchemaVersion:    schemaVersion,
+		Bridge:           bridge,
+		Excitation:       bridge.Excitation(),
+		UnbalanceTol:     unbalanceTol,
+		Target:           target,
+		InitialUnbalance: bridge.V2mV6,
+		Candidates:       make([]candidateJSON, 0, len(candidates)),
+	}
+	for _, c := range candidates {
+		RAB := c.RABValue()
+		RCD := c.RCDValue()
+		result.Candidates = append(result.Candidates, candidateJSON{
+			NPP301: c,
+			RAB:    RAB,
+			RCD:    RCD,
+			MVperV: c.SensitivityMvPerV(),
+			Vcm:    c.CommonMode(),
+			Grade:  c.Grade(target, unbalanceTol),
+			Legs: [2]legJSON{
+				{Leg: "1-2", Positions: [2]string{"RA", "RB"}, A: c.RA, A2: c.RA2, B: c.RB, Parallel: RAB},
+				{Leg: "3-4", Positions: [2]string{"RC", "RD"}, A: c.RC, A2: c.RC2, B: c.RD, Parallel: RCD},
+			},
+			Offset: c.V2mV6,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestFormatOhms(t *testing.T) {
+	for _, tc := range []struct {
+		R    float64
+		want string
+	}{
+		{0.0, "0"},
+		{4.7, "4R7"},
+		{18.0, "18R"},
+		{100.0, "100R"},
+		{2200.0, "2k2"},
+		{97600.0, "97k6"},
+		{1.0e6, "1M"},
+		{1.5e6, "1M5"},
+		{0.1, "R1"},
+		{0.01, "R01"},
+		{1.0e-5, "R00001"},
+		{1.23456789e-4, "R000123457"},
+	} {
+		if got := formatOhms(tc.R); got != tc.want {
+			t.Errorf("formatOhms(%v) = %q, want %q", tc.R, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// report.go
+// A build record of the balancing of one sensor, for archiving.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// writeReport prints the record of a balanced sensor: the measured arms,
+// the initial unbalance, the balance resistors chosen, the predicted
+// residual, and the power and source resistance with them fitted.
+func writeReport(w io.Writer, tf textFormat, when time.Time, bridge npp301.NPP301,
+	unbalanceTol float64, result npp301.SearchResult) {
+	bridge.ComputeUnbalance()
+	fmt.Fprintln(w, "NPP-301 balance record")
+	fmt.Fprintln(w, "======================")
+	fmt.Fprintf(w, "Date:              %s\n", when.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Measured arms:     R1=%.1f R2=%.1f R3=%.1f R4=%.1f ohm\n", bridge.R1, bridge.R2, bridge.R3, bridge.R4)
+	fmt.Fprintf(w, "Excitation:        %v V\n", bridge.Excitation())
+	fmt.Fprintf(w, "Initial unbalance: %.3e V (%.3f mV/V)\n", bridge.V2mV6, bridge.SensitivityMvPerV())
+	fmt.Fprintf(w, "Tolerance:         %v V about a target of %v V\n", unbalanceTol, tf.target)
+	fmt.Fprintln(w)
+	c := result.Closest
+	switch {
+	case result.AlreadyBalanced:
+		fmt.Fprintln(w, "Bridge already balanced, no resistors needed.")
+	case len(result.Candidates) > 0:
+		c = result.Candidates[0]
+		fmt.Fprintf(w, "Balance resistors, best of %d candidates:\n", len(result.Candidates))
+	case result.Tried > 0:
+		fmt.Fprintln(w, "No candidate made the cut; the closest combination tried:")
+	default:
+		fmt.Fprintln(w, "No balance resistors were tried.")
+		return
+	}
+	writeLeg(w, tf, "1-2", "RA", "RB", c.RA, c.RA2, c.RB)
+	writeLeg(w, tf, "3-4", "RC", "RD", c.RC, c.RC2, c.RD)
+	fmt.Fprintln(w)
+	p := c.Dissipation()
+	z2, z6 := c.OutputImpedance()
+	fmt.Fprintf(w, "Predicted residual: %.3e V (%.4f mV/V)\n", c.V2mV6, c.SensitivityMvPerV())
+	fmt.Fprintf(w, "Common mode:        %.4f V\n", c.CommonMode())
+	fmt.Fprintf(w, "Balance power:      %.3e W at most\n", p.MaxBalance())
+	fmt.Fprintf(w, "Source resistance:  %.1f ohm at pin 2, %.1f ohm at pin 6\n", z2, z6)
+}
+
+// writeReportFile writes the record of a balanced sensor to the named file.
+func writeReportFile(name string, tf textFormat, bridge npp301.NPP301,
+	unbalanceTol float64, result npp301.SearchResult) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	writeReport(f, tf, time.Now(), bridge, unbalanceTol, result)
+	return f.Close()
+}
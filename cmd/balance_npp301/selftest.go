@@ -0,0 +1,45 @@
+// selftest.go
+// A quick check of the build against a bridge with a known answer.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// selfTest balances the example bridge from the package tests,
+// with the default E24 values, and checks that the best candidate
+// is the known one.  It reports PASS or FAIL and whether it passed.
+func selfTest(w io.Writer) bool {
+	const unbalanceTol = 1.0e-4
+	const wantRC, wantRD, wantV2mV6 = 18.0, 91.0, 5.536e-6
+	values, err := npp301.Series("E24")
+	if err != nil {
+		fmt.Fprintln(w, "FAIL:", err)
+		return false
+	}
+	npp301.Rvalues = values
+	bridge, err := npp301.NewNPP301(1000.0, 1010.0, 1005.0, 1000.0, 1.0)
+	if err != nil {
+		fmt.Fprintln(w, "FAIL:", err)
+		return false
+	}
+	candidates := bridge.Solve(unbalanceTol)
+	if len(candidates) == 0 {
+		fmt.Fprintln(w, "FAIL: no candidates for the example bridge")
+		return false
+	}
+	best := candidates[0]
+	fmt.Fprintln(w, "example bridge R1=1000 R2=1010 R3=1005 R4=1000, best:", textFormat{}.candidate(best))
+	if best.RA != 0.0 || best.RB != 0.0 || best.RC != wantRC || best.RD != wantRD ||
+		math.Abs(best.V2mV6-wantV2mV6) > 1.0e-9 {
+		fmt.Fprintf(w, "FAIL: expected RC=%s RD=%s with v2mv6=%.3e\n", formatOhms(wantRC), formatOhms(wantRD), wantV2mV6)
+		return false
+	}
+	fmt.Fprintln(w, "PASS")
+	return true
+}
@@ -0,0 +1,89 @@
+// serial.go
+// Read the measured arms from the serial log of the characterizer board.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// serialData starts each data line of the serial log, and serialMarker
+// starts the resistances on that line.
+const (
+	serialData   = "adc readings"
+	serialMarker = "resistances"
+)
+
+// readSerialLog reads the measured arms of a batch of sensors from the
+// log printed by rs485_npp301.py as it reads the board, one reading a line:
+//
+//	adc readings a8=3309 a2=1661 a4=824 a5=822 a6=1654  resistances r1=2008.5 r2=1015.8 r3=2012.2 r4=1012.2
+//
+// Only the r1..r4 after the word resistances are used, in ohms.
+// Lines that do not start with adc readings, such as the messages
+// printed as the board starts up, are skipped.  A data line that cannot
+// be read is kept as a sensor with Err set, as for readSensors, and each
+// sensor is named for its line number.  Only a failure to read the input
+// is returned.
+func readSerialLog(r io.Reader) ([]sensor, error) {
+	scanner := bufio.NewScanner(r)
+	var sensors []sensor
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(text, serialData) {
+			continue
+		}
+		s := sensor{ID: fmt.Sprintf("line %d", line), Line: line}
+		record, err := parseSerialReadings(text)
+		if err == nil {
+			s.Bridge, err = parseSensor(record)
+		}
+		s.Err = err
+		sensors = append(sensors, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sensors, nil
+}
+
+// parseSerialReadings picks out the r1=.. r2=.. r3=.. r4=.. at the end
+// of a data line as the values of R1..R4, for parseSensor.
+func parseSerialReadings(text string) ([]string, error) {
+	_, readings, found := strings.Cut(text, serialMarker)
+	if !found {
+		return nil, fmt.Errorf("no %q on the data line", serialMarker)
+	}
+	fields := strings.Fields(readings)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("expected r1=, r2=, r3= and r4= after %q, got %d fields", serialMarker, len(fields))
+	}
+	record := make([]string, len(fields))
+	for i, field := range fields {
+		name := fmt.Sprintf("r%d", i+1)
+		value, ok := strings.CutPrefix(field, name+"=")
+		if !ok {
+			return nil, fmt.Errorf("expected %s= in place of %q", name, field)
+		}
+		record[i] = value
+	}
+	return record, nil
+}
+
+// readSerialLogFile reads the serial log from the named file,
+// or from stdin for -, so that the output of the board can be piped in.
+func readSerialLogFile(name string) ([]sensor, error) {
+	if name == "-" {
+		return readSerialLog(os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readSerialLog(f)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseSerialReadings(t *testing.T) {
+	const prefix = "adc readings a8=3309 a2=1661 a4=824 a5=822 a6=1654  "
+	for _, tc := range []struct {
+		text string
+		want []string
+		ok   bool
+	}{
+		{prefix + "resistances r1=2008.5 r2=1015.8 r3=2012.2 r4=1012.2",
+			[]string{"2008.5", "1015.8", "2012.2", "1012.2"}, true},
+		{prefix + "resistances  r1=1k r2=1k01 r3=1k r4=1k ",
+			[]string{"1k", "1k01", "1k", "1k"}, true},
+		{prefix, nil, false},
+		{prefix + "resistances r1=2008.5 r2=1015.8 r3=2012.2", nil, false},
+		{prefix + "resistances r1=2008.5 r2=1015.8 r3=2012.2 r4=1012.2 r5=1", nil, false},
+		{prefix + "resistances r1=2008.5 r3=1015.8 r2=2012.2 r4=1012.2", nil, false},
+		{prefix + "resistances r1 2008.5 r2 1015.8", nil, false},
+	} {
+		got, err := parseSerialReadings(tc.text)
+		if (err == nil) != tc.ok {
+			t.Errorf("parseSerialReadings(%q): got error %v, want ok=%v", tc.text, err, tc.ok)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("parseSerialReadings(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestReadSerialLog(t *testing.T) {
+	input := strings.Join([]string{
+		"Characterizer v0.3, reset",
+		"Now, report the NPP-301 resistances.",
+		"adc readings a8=3309 a2=1661 a4=824 a5=822 a6=1654  resistances r1=2008.5 r2=1015.8 r3=2012.2 r4=1012.2",
+		"",
+		"  adc readings a8=3310 a2=1662 a4=825 a5=822 a6=1655  resistances r1=2008.6 r2=1015.9 r3=2012.1 r4=1012.3",
+		"adc readings a8=3309 a2=1661 a4=824 a5=822 a6=1654  resistances r1=2008.5 r2=x r3=2012.2 r4=1012.2",
+		"adc readings a8=3309 a2=1661 a4=824 a5=822 a6=1654",
+		"# adc readings left out",
+	}, "\n")
+	sensors, err := readSerialLog(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		line int
+		R1   float64
+		ok   bool
+	}{{3, 2008.5, true}, {5, 2008.6, true}, {6, 0.0, false}, {7, 0.0, false}}
+	if len(sensors) != len(want) {
+		t.Fatalf("got %d sensors, want %d: %+v", len(sensors), len(want), sensors)
+	}
+	for i, w := range want {
+		s := sensors[i]
+		if s.Line != w.line || s.ID != fmt.Sprintf("line %d", w.line) {
+			t.Errorf("sensor %d: got line %d, ID %q, want line %d", i, s.Line, s.ID, w.line)
+		}
+		if (s.Err == nil) != w.ok {
+			t.Errorf("sensor %d, line %d: got error %v, want ok=%v", i, s.Line, s.Err, w.ok)
+		}
+		if w.ok && s.Bridge.R1 != w.R1 {
+			t.Errorf("sensor %d, line %d: got R1=%v, want %v", i, s.Line, s.Bridge.R1, w.R1)
+		}
+	}
+}
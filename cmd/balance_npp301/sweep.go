@@ -0,0 +1,55 @@
+// sweep.go
+// A table of the output as one balance resistor sweeps the series.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer/npp301"
+)
+
+// sweepPosition returns a pointer to the balance resistor of this name.
+func sweepPosition(npp *npp301.NPP301, name string) (*float64, error) {
+	switch name {
+	case "RA":
+		return &npp.RA, nil
+	case "RB":
+		return &npp.RB, nil
+	case "RC":
+		return &npp.RC, nil
+	case "RD":
+		return &npp.RD, nil
+	case "RA2":
+		return &npp.RA2, nil
+	case "RC2":
+		return &npp.RC2, nil
+	}
+	return nil, fmt.Errorf("unknown balance resistor %q to sweep; expected RA, RB, RC, RD, RA2 or RC2", name)
+}
+
+// writeSweep prints the output of the bridge npp as the balance resistor
+// of this name takes each of the values, the others being held as set,
+// and marks where the output crosses the target.
+func writeSweep(w io.Writer, tf textFormat, npp npp301.NPP301, name string, values []float64, target float64) error {
+	R, err := sweepPosition(&npp, name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%8s %12s %10s\n", name, "v2mv6", "mV/V")
+	var last float64
+	for i, value := range values {
+		*R = value
+		npp.ComputeUnbalance()
+		d := npp.V2mV6 - target
+		mark := ""
+		if i > 0 && ((last < 0.0 && d >= 0.0) || (last > 0.0 && d <= 0.0)) {
+			mark = " <- crosses the target"
+		}
+		fmt.Fprintf(w, "%8s %12s %10s%s\n", formatOhms(value), tf.num("%.4e", npp.V2mV6),
+			tf.num("%.4f", npp.SensitivityMvPerV()), mark)
+		last = d
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+module github.com/pajacobs-ghub/pic18f16q41-npp-301-characterizer
+
+go 1.21
@@ -0,0 +1,55 @@
+// armswap.go
+// Balancing by replacing one arm of a discrete bridge.
+
+package npp301
+
+import (
+	"math"
+	"sort"
+)
+
+// ArmSwap is the suggestion to replace one arm of the bridge
+// with a standard value, in place of fitting balance resistors.
+type ArmSwap struct {
+	Arm    int     // the arm replaced, 1 to 4
+	Ideal  float64 // the exact value that would bring the output to the target
+	Value  float64 // the nearest value in the series
+	Bridge NPP301  // the bridge with the arm replaced, its output computed
+}
+
+// ArmSwaps returns, for each arm that can be replaced, the nearest value
+// in series to the one that would bring the output to target, with
+// the best of the suggestions first.  The balance resistors and leads
+// of the bridge are kept as they are.
+func (bridge *NPP301) ArmSwaps(target float64, series []float64) []ArmSwap {
+	npp := *bridge
+	npp.ComputeUnbalance()
+	R1, R2, R3, R4 := npp.arms()
+	vexc := npp.Excitation()
+	// Each pin must sit at v2 = v6 + target, either pin being moved
+	// by its own arms.  The ideal values here include the leads.
+	v2, v6 := npp.V6+target, npp.V2-target
+	ideals := [4]float64{
+		(R2 + npp.RABValue()) * (vexc/v2 - 1.0),
+		v2/(vexc-v2)*R1 - npp.RABValue(),
+		(R4 + npp.RCDValue()) * (vexc/v6 - 1.0),
+		v6/(vexc-v6)*R3 - npp.RCDValue(),
+	}
+	leads := [4]float64{npp.RL1, npp.RL2, npp.RL3, npp.RL4}
+	var swaps []ArmSwap
+	for i, ideal := range ideals {
+		ideal -= leads[i]
+		if !(ideal > 0.0) || math.IsInf(ideal, 1) {
+			continue
+		}
+		swap := ArmSwap{Arm: i + 1, Ideal: ideal, Value: NearestSeriesValue(ideal, series), Bridge: npp}
+		arms := [4]*float64{&swap.Bridge.R1, &swap.Bridge.R2, &swap.Bridge.R3, &swap.Bridge.R4}
+		*arms[i] = swap.Value
+		swap.Bridge.ComputeUnbalance()
+		swaps = append(swaps, swap)
+	}
+	sort.SliceStable(swaps, func(i, j int) bool {
+		return math.Abs(swaps[i].Bridge.V2mV6-target) < math.Abs(swaps[j].Bridge.V2mV6-target)
+	})
+	return swaps
+}
@@ -0,0 +1,28 @@
+package npp301
+
+import (
+	"math"
+	"testing"
+)
+
+func TestArmSwapsIdealBalances(t *testing.T) {
+	swaps := testBridge.ArmSwaps(0.0, SeriesValues(E96, 0, 4))
+	if len(swaps) != 4 {
+		t.Fatalf("expected a suggestion for each arm, got %d", len(swaps))
+	}
+	for _, swap := range swaps {
+		// The exact value in the arm balances the bridge.
+		npp := testBridge
+		arms := [4]*float64{&npp.R1, &npp.R2, &npp.R3, &npp.R4}
+		*arms[swap.Arm-1] = swap.Ideal
+		npp.ComputeUnbalance()
+		if math.Abs(npp.V2mV6) > eps {
+			t.Errorf("arm R%d at %v gives v2-v6 = %v, want 0", swap.Arm, swap.Ideal, npp.V2mV6)
+		}
+	}
+	for i := 1; i < len(swaps); i++ {
+		if math.Abs(swaps[i].Bridge.V2mV6) < math.Abs(swaps[i-1].Bridge.V2mV6) {
+			t.Errorf("suggestions are not in order of the output")
+		}
+	}
+}
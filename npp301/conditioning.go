@@ -0,0 +1,59 @@
+// conditioning.go
+// Detection of bridges for which the computed output cannot be trusted.
+
+package npp301
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Limits beyond which an arm, with its lead, is taken as open or as
+// shorted, and the ratio of the arms of a leg beyond which the output
+// pin is pinned to the supply or to ground.
+const (
+	openArm   = 1.0e9
+	shortArm  = 1.0e-3
+	ratioLegs = 1.0e6
+)
+
+// Conditioning checks the bare bridge for conditions in which v2-v6
+// loses its precision or takes extreme values: an arm nearly open or
+// nearly shorted, a leg whose arms are so unequal that its output pin
+// sits at a rail, an output lost in the rounding of v2 and v6, or
+// a result that is not a finite number.  It returns nil for a usable
+// bridge, or an error describing each problem.
+func (bridge *NPP301) Conditioning() error {
+	var errs []error
+	R1, R2, R3, R4 := bridge.arms()
+	for i, R := range []float64{R1, R2, R3, R4} {
+		switch {
+		case math.IsNaN(R) || math.IsInf(R, 0):
+			errs = append(errs, fmt.Errorf("arm R%d is %v", i+1, R))
+		case R > openArm:
+			errs = append(errs, fmt.Errorf("arm R%d of %.3g ohm is nearly open", i+1, R))
+		case R < shortArm:
+			errs = append(errs, fmt.Errorf("arm R%d of %.3g ohm is nearly shorted", i+1, R))
+		}
+	}
+	for _, leg := range []struct {
+		name        string
+		top, bottom float64
+	}{{"1-2", R1, R2}, {"3-4", R3, R4}} {
+		if ratio := math.Max(leg.top/leg.bottom, leg.bottom/leg.top); ratio > ratioLegs {
+			errs = append(errs, fmt.Errorf("the arms of leg %s differ by a factor of %.3g, pinning its output to a rail", leg.name, ratio))
+		}
+	}
+	npp := bridge.Bare()
+	switch {
+	case math.IsNaN(npp.V2mV6) || math.IsInf(npp.V2mV6, 0):
+		errs = append(errs, fmt.Errorf("the output v2-v6 is %v", npp.V2mV6))
+	case npp.V2mV6 != 0.0 && math.Abs(npp.V2mV6) < 1.0e3*epsilon*math.Max(math.Abs(npp.V2), math.Abs(npp.V6)):
+		errs = append(errs, fmt.Errorf("the output v2-v6 of %.3g V is within the rounding of v2 and v6", npp.V2mV6))
+	}
+	return errors.Join(errs...)
+}
+
+// epsilon is the relative precision of a float64.
+const epsilon = 0x1p-52
@@ -0,0 +1,34 @@
+package npp301
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestConditioning(t *testing.T) {
+	if err := testBridge.Conditioning(); err != nil {
+		t.Errorf("unexpected problem with a typical bridge: %v", err)
+	}
+	for _, tc := range []struct {
+		bridge NPP301
+		want   string
+	}{
+		{NPP301{R1: 1.0e12, R2: 1.0e12, R3: 1000.0, R4: 1000.0}, "R1 of 1e+12 ohm is nearly open"},
+		{NPP301{R1: 1000.0, R2: 1.0e-6, R3: 1000.0, R4: 1000.0}, "R2 of 1e-06 ohm is nearly shorted"},
+		{NPP301{R1: 1000.0, R2: 1000.0, R3: 0.01, R4: 1.0e5}, "leg 3-4 differ by a factor of 1e+07"},
+		{NPP301{R1: 1000.0, R2: 1000.0, R3: math.Inf(1), R4: 1000.0}, "arm R3 is +Inf"},
+		{NPP301{R1: 1000.0, R2: 1000.0, R3: 1000.0, R4: 1000.0 * (1.0 + 4.0e-16)}, "within the rounding"},
+	} {
+		err := tc.bridge.Conditioning()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Conditioning of %+v = %v, want %q", tc.bridge, err, tc.want)
+		}
+	}
+}
+
+func TestParallelRCancelling(t *testing.T) {
+	if got := ParallelR(100.0, -100.0); !math.IsInf(got, 1) {
+		t.Errorf("ParallelR(100, -100) = %v, want +Inf", got)
+	}
+}
@@ -0,0 +1,82 @@
+// cost.go
+// Price of the balance resistors of a candidate, from a price list.
+
+package npp301
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CostTable holds the price of each resistor value, such as from
+// a supplier's price list.  A value not in Prices costs Default.
+type CostTable struct {
+	Prices  map[float64]float64
+	Default float64
+}
+
+// Price returns the price of one resistor of value R.
+func (table CostTable) Price(R float64) float64 {
+	if price, ok := table.Prices[R]; ok {
+		return price
+	}
+	return table.Default
+}
+
+// ReadCostTable reads a price list with a resistance in ohms and a price
+// on each line, separated by a comma or by spaces.  Blank lines and lines
+// starting with # are skipped.  Values not listed cost flat.
+// Every line that cannot be read is reported in the error.
+func ReadCostTable(r io.Reader, flat float64) (CostTable, error) {
+	table := CostTable{Prices: map[float64]float64{}, Default: flat}
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(c rune) bool { return c == ',' || c == ' ' || c == '\t' })
+		if len(fields) != 2 {
+			errs = append(errs, fmt.Errorf("line %d: expected a resistance and a price, got %q", line, text))
+			continue
+		}
+		value, err1 := strconv.ParseFloat(fields[0], 64)
+		price, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || !(value > 0.0) || math.IsInf(value, 1) || err2 != nil || !(price >= 0.0) {
+			errs = append(errs, fmt.Errorf("line %d: expected a positive resistance and a price, got %q", line, text))
+			continue
+		}
+		table.Prices[value] = price
+	}
+	if err := scanner.Err(); err != nil {
+		return CostTable{}, err
+	}
+	if len(errs) > 0 {
+		return CostTable{}, errors.Join(errs...)
+	}
+	return table, nil
+}
+
+// Cost returns the combined price of the fitted balance resistors,
+// RA, RB, RC and RD and any second resistors in series.
+func (bridge *NPP301) Cost(table CostTable) float64 {
+	cost := 0.0
+	for _, R := range []float64{bridge.RA, bridge.RB, bridge.RC, bridge.RD, bridge.RA2, bridge.RC2} {
+		if R != 0.0 {
+			cost += table.Price(R)
+		}
+	}
+	return cost
+}
+
+// SortByCost orders the candidates by the price of their balance
+// resistors, cheapest first, keeping the closeness order among equals.
+func SortByCost(candidates []NPP301, table CostTable) {
+	sortByKey(candidates, func(c *NPP301) float64 { return c.Cost(table) })
+}
@@ -0,0 +1,41 @@
+package npp301
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadCostTable(t *testing.T) {
+	table, err := ReadCostTable(strings.NewReader("# value, price\n18,0.02\n\n91 0.05\n"), 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ R, want float64 }{{18.0, 0.02}, {91.0, 0.05}, {100.0, 0.01}} {
+		if got := table.Price(tc.R); got != tc.want {
+			t.Errorf("Price(%v) = %v, want %v", tc.R, got, tc.want)
+		}
+	}
+	_, err = ReadCostTable(strings.NewReader("18,0.02\n91\nx,1\n"), 0.01)
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected errors for lines 2 and 3, got %v", err)
+	}
+}
+
+func TestSortByCost(t *testing.T) {
+	const tol = 1.0e-4
+	table := CostTable{Prices: map[float64]float64{15.0: 0.001}, Default: 0.01}
+	candidates := testBridge.SolveWith(tol, SearchOptions{Single: true})
+	SortByCost(candidates, table)
+	if len(candidates) == 0 {
+		t.Fatal("expected candidates")
+	}
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].Cost(table) < candidates[i-1].Cost(table) {
+			t.Fatalf("candidate %d costs less than the one before it", i)
+		}
+	}
+	if got := candidates[0].Cost(table); math.Abs(got-0.001) > eps {
+		t.Errorf("cheapest candidate %+v costs %v, want the single 15 ohm at 0.001", candidates[0], got)
+	}
+}
@@ -0,0 +1,49 @@
+// nearest.go
+// Snapping a resistance to the values on hand.
+
+package npp301
+
+import (
+	"math"
+	"sort"
+)
+
+// NearestSeriesValue returns the value in series closest to target.
+// The series must be in increasing order, as made by SeriesValues.
+func NearestSeriesValue(target float64, series []float64) float64 {
+	i := sort.SearchFloat64s(series, target)
+	if i == len(series) {
+		return series[len(series)-1]
+	}
+	if i > 0 && target-series[i-1] < series[i]-target {
+		return series[i-1]
+	}
+	return series[i]
+}
+
+// NearestPair returns the two values from Rvalues, smaller first,
+// whose parallel combination is closest to target.
+// For each first resistor, the combination varies steadily with
+// the second, so only the two values either side of the ideal
+// second resistor need to be tried.
+func NearestPair(target float64) (float64, float64) {
+	bestA, bestB := Rvalues[0], Rvalues[0]
+	bestErr := math.Inf(1)
+	for _, Ra := range Rvalues {
+		i := len(Rvalues) - 1
+		if Ra > target {
+			ideal := 1.0 / (1.0/target - 1.0/Ra)
+			i = sort.SearchFloat64s(Rvalues, ideal)
+		}
+		for _, k := range []int{i - 1, i} {
+			if k < 0 || k >= len(Rvalues) {
+				continue
+			}
+			Rb := Rvalues[k]
+			if err := math.Abs(ParallelR(Ra, Rb) - target); err < bestErr {
+				bestA, bestB, bestErr = math.Min(Ra, Rb), math.Max(Ra, Rb), err
+			}
+		}
+	}
+	return bestA, bestB
+}
@@ -0,0 +1,40 @@
+package npp301
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNearestSeriesValue(t *testing.T) {
+	tests := []struct{ target, want float64 }{
+		{0.1, 1.0},
+		{4.6, 4.7},
+		{4.8, 4.7},
+		{12345.0, 12000.0},
+		{1.0e6, 91.0e3},
+	}
+	for _, tc := range tests {
+		if got := NearestSeriesValue(tc.target, Rvalues); got != tc.want {
+			t.Errorf("NearestSeriesValue(%v) = %v, want %v", tc.target, got, tc.want)
+		}
+	}
+}
+
+func TestNearestPairMatchesBruteForce(t *testing.T) {
+	for _, target := range []float64{0.4, 3.3, 15.0, 123.4, 999.0, 4321.0, 50.0e3} {
+		Ra, Rb := NearestPair(target)
+		if Ra > Rb {
+			t.Errorf("NearestPair(%v) = %v, %v, want the smaller first", target, Ra, Rb)
+		}
+		got := math.Abs(ParallelR(Ra, Rb) - target)
+		want := math.Inf(1)
+		for _, Ra := range Rvalues {
+			for _, Rb := range Rvalues {
+				want = math.Min(want, math.Abs(ParallelR(Ra, Rb)-target))
+			}
+		}
+		if got != want {
+			t.Errorf("NearestPair(%v) = %v, %v, error %v, brute force gives %v", target, Ra, Rb, got, want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// npp301.go
+// Given the measures bridge resistances, compute our options for balance resistors.
+// Peter J. 2025-03-13
+
+// Package npp301 models the NPP-301 pressure-sensor bridge together with
+// the balance resistors that are placed at the bottom of each leg.
+package npp301
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rvalues are the resistor values used by Solve.
+// Assume that we can draw resistor values from the E24 series.
+var Rvalues []float64 = SeriesValues(E24, DefaultMinExp, DefaultMaxExp)
+
+// NPP301 holds the measured arm resistances of the bridge, R1..R4,
+// and the balance resistors, RA..RD, added in parallel pairs
+// at the bottom of the legs.  A zero balance resistance means that
+// the resistor is not fitted (see ParallelR).  Vexc is the excitation
+// voltage applied across the bridge; a zero value is taken as 1 volt
+// so that V2mV6 is then a fraction of the excitation.  A negative value,
+// for a bridge wired with the excitation reversed, flips the output.
+// V2mV6 is the output of the bridge, in volts, as computed by ComputeUnbalance.
+// RA2 and RC2, when fitted, are second resistors in series with RA and RC,
+// so that a balance position can be made up from two standard values.
+// V2 and V6 are the voltages at the output pins, and I12 and I34
+// the currents in legs 1-2 and 3-4, also set by ComputeUnbalance.
+// TCArms and TCBalance are the optional temperature coefficients,
+// in ppm/degC, of the bridge arms and of the balance resistors.
+// RL1..RL4 are the optional lead resistances, such as those of a cable
+// to a remote sensor, that add to the arms R1..R4 in the bridge equations.
+// RS2 and RS6 are the optional resistances of separate sense lines from
+// the output pins to the amplifier, on a board that brings them out; the
+// sense current being negligible, they drop no voltage and so leave
+// V2 and V6 as they are, but they add to the source resistance.
+// RADC is the optional input resistance, to ground, of the ADC that
+// reads pins 2 and 6, such as that of the PIC18F16Q41 with its sample
+// and hold; when it is nonzero, V2 and V6 are the voltages that it reads,
+// each pin being divided down across its source resistance, sense line
+// included, and RADC.  Zero leaves the output pins unloaded.
+type NPP301 struct {
+	R1    float64 `json:"R1"`
+	R2    float64 `json:"R2"`
+	R3    float64 `json:"R3"`
+	R4    float64 `json:"R4"`
+	RA    float64 `json:"RA"`
+	RB    float64 `json:"RB"`
+	RC    float64 `json:"RC"`
+	RD    float64 `json:"RD"`
+	RA2   float64 `json:"RA2,omitempty"`
+	RC2   float64 `json:"RC2,omitempty"`
+	Vexc  float64 `json:"Vexc"`
+	V2mV6 float64 `json:"v2mv6"`
+	V2    float64 `json:"v2"`
+	V6    float64 `json:"v6"`
+	I12   float64 `json:"i12"`
+	I34   float64 `json:"i34"`
+
+	TCArms    float64 `json:"TCArms,omitempty"`
+	TCBalance float64 `json:"TCBalance,omitempty"`
+
+	RL1 float64 `json:"RL1,omitempty"`
+	RL2 float64 `json:"RL2,omitempty"`
+	RL3 float64 `json:"RL3,omitempty"`
+	RL4 float64 `json:"RL4,omitempty"`
+
+	RS2 float64 `json:"RS2,omitempty"`
+	RS6 float64 `json:"RS6,omitempty"`
+
+	RADC float64 `json:"RADC,omitempty"`
+}
+
+// NewNPP301 returns a bridge with the measured arm resistances
+// and excitation voltage, after checking that they make sense.
+// This is the documented way to set up a bridge; a struct literal
+// still works but its values are not checked.
+func NewNPP301(R1, R2, R3, R4, Vexc float64) (*NPP301, error) {
+	for i, R := range []float64{R1, R2, R3, R4} {
+		if !(R > 0.0) || math.IsInf(R, 1) {
+			return nil, fmt.Errorf("arm resistance R%d must be positive and finite, got %v", i+1, R)
+		}
+	}
+	if Vexc == 0.0 || math.IsNaN(Vexc) || math.IsInf(Vexc, 0) {
+		return nil, fmt.Errorf("excitation voltage must be nonzero and finite, got %v", Vexc)
+	}
+	return &NPP301{R1: R1, R2: R2, R3: R3, R4: R4, Vexc: Vexc}, nil
+}
+
+// ParallelR returns the resistance of Ra and Rb in parallel.
+// A zero value means that the resistor is not fitted, so a single
+// resistor gives its own value.  With neither resistor fitted,
+// the balance position is jumpered and the resistance is zero.
+func ParallelR(Ra, Rb float64) float64 {
+	var Rab float64
+	if Ra == 0.0 {
+		Rab = Rb
+	} else if Rb == 0.0 {
+		Rab = Ra
+	} else if g := 1.0/Ra + 1.0/Rb; g == 0.0 {
+		// Only a negative resistance can cancel the other,
+		// which leaves no current path, rather than dividing by zero.
+		Rab = math.Inf(1)
+	} else {
+		Rab = 1.0 / g
+	}
+	return Rab
+}
+
+// arms returns the arm resistances, each with its lead resistance.
+func (bridge *NPP301) arms() (float64, float64, float64, float64) {
+	return bridge.R1 + bridge.RL1, bridge.R2 + bridge.RL2, bridge.R3 + bridge.RL3, bridge.R4 + bridge.RL4
+}
+
+// RABValue returns the balance resistance on leg 1-2, RA in series
+// with RA2, in parallel with RB.  It is the one place this is worked out.
+func (bridge *NPP301) RABValue() float64 {
+	return ParallelR(bridge.RA+bridge.RA2, bridge.RB)
+}
+
+// RCDValue returns the balance resistance on leg 3-4, RC in series
+// with RC2, in parallel with RD.
+func (bridge *NPP301) RCDValue() float64 {
+	return ParallelR(bridge.RC+bridge.RC2, bridge.RD)
+}
+
+// TrimRatio returns the larger of RAB/R2 and RCD/R4, each balance
+// resistance against the arm below the output pin that it is in series
+// with, leads included.  A ratio that is not small means a trim that
+// swamps the arm, so that the output is touchy to the balance resistors.
+func (bridge *NPP301) TrimRatio() float64 {
+	_, R2, _, R4 := bridge.arms()
+	return math.Max(bridge.RABValue()/R2, bridge.RCDValue()/R4)
+}
+
+// WithExcitation returns a copy of the bridge run at the excitation vexc,
+// its output computed.  With nothing else changed, the output scales in
+// proportion to the excitation, the balance being ratiometric; it is
+// a target in volts that does not.
+func (bridge *NPP301) WithExcitation(vexc float64) NPP301 {
+	npp := *bridge
+	npp.Vexc = vexc
+	npp.ComputeUnbalance()
+	return npp
+}
+
+// Excitation returns the excitation voltage, defaulting to 1 volt.
+func (bridge *NPP301) Excitation() float64 {
+	if bridge.Vexc == 0.0 {
+		return 1.0
+	}
+	return bridge.Vexc
+}
+
+// LegCurrent returns the current in one leg of the bridge, across
+// the excitation vexc, with the top arm Rtop, the bottom arm Rbottom
+// and the balance resistance Rbal all in series.
+func LegCurrent(vexc, Rtop, Rbottom, Rbal float64) float64 {
+	return vexc / (Rtop + Rbottom + Rbal)
+}
+
+// NodeVoltage returns the voltage at the output pin of a leg,
+// below the top arm Rtop that carries the leg current i.
+func NodeVoltage(vexc, Rtop, i float64) float64 {
+	return vexc - Rtop*i
+}
+
+// LoadedVoltage returns the voltage read at an output pin of open-circuit
+// voltage v and source resistance z by an input of resistance Rin to
+// ground.  A zero Rin is no load, and v is returned as it is.
+func LoadedVoltage(v, z, Rin float64) float64 {
+	if Rin == 0.0 {
+		return v
+	}
+	return v * Rin / (Rin + z)
+}
+
+// ComputeUnbalance sets V2mV6 for the current resistor values.
+func (bridge *NPP301) ComputeUnbalance() {
+	// Balance resistors are in parallel pairs.
+	RAB := bridge.RABValue()
+	RCD := bridge.RCDValue()
+	// Compute currents in each arm of the bridge.
+	R1, R2, R3, R4 := bridge.arms()
+	vexc := bridge.Excitation()
+	i12 := LegCurrent(vexc, R1, R2, RAB)
+	i34 := LegCurrent(vexc, R3, R4, RCD)
+	// Compute voltages at pins 2 and 6.
+	// These are the output pins for the NPP-301.
+	// The sense lines, if any, carry no current and drop nothing,
+	// unless the ADC input loads them.
+	v2 := LoadedVoltage(NodeVoltage(vexc, R1, i12), ParallelR(R1, R2+RAB)+bridge.RS2, bridge.RADC)
+	v6 := LoadedVoltage(NodeVoltage(vexc, R3, i34), ParallelR(R3, R4+RCD)+bridge.RS6, bridge.RADC)
+	bridge.V2mV6 = v2 - v6
+	bridge.V2, bridge.V6 = v2, v6
+	bridge.I12, bridge.I34 = i12, i34
+	return
+}
+
+// Bare returns a copy of the bridge without balance resistors,
+// its output computed, as the sensor is before it is balanced.
+func (bridge *NPP301) Bare() NPP301 {
+	npp := *bridge
+	npp.RA, npp.RB, npp.RC, npp.RD = 0.0, 0.0, 0.0, 0.0
+	npp.RA2, npp.RC2 = 0.0, 0.0
+	npp.ComputeUnbalance()
+	return npp
+}
+
+// NaturalOffset returns the output v2-v6, in volts, of the bare bridge,
+// with no balance resistors fitted, such as to log for process control.
+func (bridge *NPP301) NaturalOffset() float64 {
+	npp := bridge.Bare()
+	return npp.V2mV6
+}
+
+// AdjustsLeg34 reports whether the output of the bridge, without balance
+// resistors, is brought to target by adding resistance to leg 3-4,
+// rather than to leg 1-2.  Resistance on leg 3-4 lowers v2-v6 for
+// a positive excitation, and raises it for a reversed one.
+func (bridge *NPP301) AdjustsLeg34(target float64) bool {
+	return (bridge.NaturalOffset() > target) == (bridge.Excitation() > 0.0)
+}
+
+// IdealBalance returns the exact balance resistances, RAB on leg 1-2
+// and RCD on leg 3-4, that bring the output to target with no balance
+// resistance on the other leg.  Only the leg that the search adjusts,
+// as given by AdjustsLeg34, has a nonzero value.  The discrete
+// candidates come as close to this as the resistor values allow.
+// With the ADC input loading the pins, the closed form no longer holds
+// and the balance resistance is found by bisection on the leg model.
+func (bridge *NPP301) IdealBalance(target float64) (float64, float64) {
+	npp := bridge.Bare()
+	R1, R2, R3, R4 := npp.arms()
+	vexc := npp.Excitation()
+	leg34 := npp.AdjustsLeg34(target)
+	if npp.RADC != 0.0 {
+		leg := newLegOutput(&npp, leg34)
+		R := leg.solve(target)
+		if leg34 {
+			return 0.0, R
+		}
+		return R, 0.0
+	}
+	// Each output pin sits at the fraction f = (R2+RAB)/(R1+R2+RAB)
+	// of the excitation, for leg 1-2, so RAB = f*R1/(1-f) - R2.
+	if leg34 {
+		f := (npp.V2 - target) / vexc
+		return 0.0, f*R3/(1.0-f) - R4
+	}
+	f := (npp.V6 + target) / vexc
+	return f*R1/(1.0-f) - R2, 0.0
+}
+
+// Trimpot returns the wiper setting of a trimmer of value Rpot that,
+// wired as a variable resistor in the balance position of the adjusted
+// leg, brings the output to target.  The setting is given as the fraction
+// of the track in circuit and as the resistance that this makes.
+func (bridge *NPP301) Trimpot(Rpot, target float64) (float64, float64, error) {
+	if !(Rpot > 0.0) {
+		return 0.0, 0.0, fmt.Errorf("trimpot value must be positive, got %v", Rpot)
+	}
+	RAB, RCD := bridge.IdealBalance(target)
+	R := RAB + RCD
+	if R > Rpot {
+		return 0.0, 0.0, fmt.Errorf("trimpot of %v ohm is too small for the %.3f ohm needed", Rpot, R)
+	}
+	return R / Rpot, R, nil
+}
+
+// CommonMode returns the mean voltage of the output pins, (v2+v6)/2,
+// which must sit within the input range of the instrumentation amplifier.
+func (bridge *NPP301) CommonMode() float64 {
+	return 0.5 * (bridge.V2 + bridge.V6)
+}
+
+// OutputImpedance returns the Thevenin source resistance seen at
+// pins 2 and 6, each arm to the supply in parallel with the rest
+// of its leg, balance resistors included, and the sense line,
+// if any, in series.
+func (bridge *NPP301) OutputImpedance() (float64, float64) {
+	R1, R2, R3, R4 := bridge.arms()
+	z2 := ParallelR(R1, R2+bridge.RABValue()) + bridge.RS2
+	z6 := ParallelR(R3, R4+bridge.RCDValue()) + bridge.RS6
+	return z2, z6
+}
+
+// SensitivityMvPerV returns the bridge output in millivolts per volt
+// of excitation, the units used for offset in the sensor datasheets.
+func (bridge *NPP301) SensitivityMvPerV() float64 {
+	return bridge.V2mV6 / bridge.Excitation() * 1000.0
+}
@@ -0,0 +1,295 @@
+package npp301
+
+import (
+	"math"
+	"testing"
+)
+
+// eps is the tolerance for comparing computed resistances and voltages.
+const eps = 1.0e-12
+
+func TestParallelR(t *testing.T) {
+	tests := []struct {
+		name   string
+		Ra, Rb float64
+		want   float64
+	}{
+		{"both nonzero", 1000.0, 1000.0, 500.0},
+		{"both nonzero unequal", 100.0, 400.0, 80.0},
+		{"Ra not fitted", 0.0, 220.0, 220.0},
+		{"Rb not fitted", 220.0, 0.0, 220.0},
+		{"both zero", 0.0, 0.0, 0.0},
+	}
+	for _, tc := range tests {
+		got := ParallelR(tc.Ra, tc.Rb)
+		if math.Abs(got-tc.want) > eps*math.Max(1.0, tc.want) {
+			t.Errorf("%s: ParallelR(%v, %v) = %v, want %v", tc.name, tc.Ra, tc.Rb, got, tc.want)
+		}
+	}
+}
+
+func TestComputeUnbalance(t *testing.T) {
+	tests := []struct {
+		name   string
+		bridge NPP301
+		want   float64
+	}{
+		// All arms equal gives a balanced bridge.
+		{"balanced", NPP301{R1: 1000.0, R2: 1000.0, R3: 1000.0, R4: 1000.0}, 0.0},
+		{"balanced 5V", NPP301{R1: 1000.0, R2: 1000.0, R3: 1000.0, R4: 1000.0, Vexc: 5.0}, 0.0},
+		// v2 = 1 - 1000/2010, v6 = 1 - 1005/2005
+		// v2 is higher, so the unbalance is positive.
+		{"unbalanced", NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0},
+			1010.0/2010.0 - 1000.0/2005.0},
+		// RCD = 1k raises v6 to 2/3 while v2 stays at 1/2.
+		{"balance resistors on leg 3-4",
+			NPP301{R1: 1000.0, R2: 1000.0, R3: 1000.0, R4: 1000.0, RC: 2000.0, RD: 2000.0},
+			0.5 - 2.0/3.0},
+		// The same on leg 1-2 raises v2 instead.
+		{"balance resistors on leg 1-2",
+			NPP301{R1: 1000.0, R2: 1000.0, R3: 1000.0, R4: 1000.0, RA: 2000.0, RB: 2000.0},
+			2.0/3.0 - 0.5},
+	}
+	for _, tc := range tests {
+		bridge := tc.bridge
+		bridge.ComputeUnbalance()
+		if math.Abs(bridge.V2mV6-tc.want) > eps {
+			t.Errorf("%s: V2mV6 = %v, want %v", tc.name, bridge.V2mV6, tc.want)
+		}
+	}
+}
+
+func TestCommonMode(t *testing.T) {
+	npp := NPP301{R1: 1000.0, R2: 1000.0, R3: 1000.0, R4: 1000.0, RC: 2000.0, RD: 2000.0, Vexc: 3.0}
+	npp.ComputeUnbalance()
+	// v2 = 1.5 V and v6 = 2.0 V
+	if got, want := npp.CommonMode(), 1.75; math.Abs(got-want) > eps {
+		t.Errorf("CommonMode = %v, want %v", got, want)
+	}
+}
+
+func TestNewNPP301(t *testing.T) {
+	bridge, err := NewNPP301(1000.0, 1010.0, 1005.0, 1000.0, 5.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bridge.R2 != 1010.0 || bridge.Vexc != 5.0 {
+		t.Errorf("got %+v", *bridge)
+	}
+	if _, err := NewNPP301(1000.0, 1010.0, 1005.0, 1000.0, -5.0); err != nil {
+		t.Errorf("a reversed excitation should be accepted: %v", err)
+	}
+	bad := [][5]float64{
+		{0.0, 1000.0, 1000.0, 1000.0, 1.0},
+		{1000.0, -1.0, 1000.0, 1000.0, 1.0},
+		{1000.0, 1000.0, math.NaN(), 1000.0, 1.0},
+		{1000.0, 1000.0, 1000.0, math.Inf(1), 1.0},
+		{1000.0, 1000.0, 1000.0, 1000.0, 0.0},
+		{1000.0, 1000.0, 1000.0, 1000.0, math.Inf(-1)},
+	}
+	for _, b := range bad {
+		if _, err := NewNPP301(b[0], b[1], b[2], b[3], b[4]); err == nil {
+			t.Errorf("expected an error for %v", b)
+		}
+	}
+}
+
+func TestOutputImpedance(t *testing.T) {
+	npp := NPP301{R1: 1000, R2: 1000, R3: 1000, R4: 1000, RC: 1000}
+	z2, z6 := npp.OutputImpedance()
+	if math.Abs(z2-500.0) > eps || math.Abs(z6-2000.0/3.0) > eps {
+		t.Errorf("OutputImpedance = %v, %v, want 500, 666.7", z2, z6)
+	}
+}
+
+func TestIdealBalance(t *testing.T) {
+	bridges := []NPP301{
+		{R1: 1000, R2: 1010, R3: 1005, R4: 1000},
+		{R1: 1010, R2: 1000, R3: 1000, R4: 1005, Vexc: 5.0},
+	}
+	for _, npp := range bridges {
+		for _, target := range []float64{0.0, 0.002} {
+			RAB, RCD := npp.IdealBalance(target)
+			if (RAB == 0.0) == (RCD == 0.0) || RAB < 0.0 || RCD < 0.0 {
+				t.Errorf("IdealBalance(%v) of %+v = %v, %v, want one positive value", target, npp, RAB, RCD)
+			}
+			// A single resistor of the ideal value balances the bridge exactly.
+			c := npp
+			c.RA, c.RC = RAB, RCD
+			c.ComputeUnbalance()
+			if math.Abs(c.V2mV6-target) > eps {
+				t.Errorf("with RAB=%v RCD=%v, v2-v6 = %v, want %v", RAB, RCD, c.V2mV6, target)
+			}
+		}
+	}
+}
+
+func TestLeadResistance(t *testing.T) {
+	// Leads add to the arms, so moving resistance into the leads
+	// leaves the output unchanged.
+	npp := NPP301{R1: 1000, R2: 1010, R3: 1005, R4: 1000}
+	npp.ComputeUnbalance()
+	leads := NPP301{R1: 990, R2: 1000, R3: 1000, R4: 995, RL1: 10, RL2: 10, RL3: 5, RL4: 5}
+	leads.ComputeUnbalance()
+	if math.Abs(leads.V2mV6-npp.V2mV6) > eps {
+		t.Errorf("with leads, v2-v6 = %v, want %v", leads.V2mV6, npp.V2mV6)
+	}
+	RAB, RCD := leads.IdealBalance(0.0)
+	wantRAB, wantRCD := npp.IdealBalance(0.0)
+	if math.Abs(RAB-wantRAB) > 1.0e-9 || math.Abs(RCD-wantRCD) > 1.0e-9 {
+		t.Errorf("with leads, IdealBalance = %v, %v, want %v, %v", RAB, RCD, wantRAB, wantRCD)
+	}
+}
+
+func TestTrimpot(t *testing.T) {
+	npp := NPP301{R1: 1000, R2: 1010, R3: 1005, R4: 1000}
+	_, RCD := npp.IdealBalance(0.0)
+	fraction, R, err := npp.Trimpot(100.0, 0.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if R != RCD || math.Abs(fraction-RCD/100.0) > eps {
+		t.Errorf("Trimpot(100) = %v, %v, want %v, %v", fraction, R, RCD/100.0, RCD)
+	}
+	if _, _, err := npp.Trimpot(10.0, 0.0); err == nil {
+		t.Errorf("expected an error for a trimpot smaller than %v", RCD)
+	}
+}
+
+func TestLegCurrentAndNodeVoltage(t *testing.T) {
+	// 5 V across 1000 + 1000 + 500 ohm gives 2 mA,
+	// and the pin sits 2 V below the supply.
+	i := LegCurrent(5.0, 1000.0, 1000.0, 500.0)
+	if math.Abs(i-2.0e-3) > eps {
+		t.Errorf("LegCurrent = %v, want 2e-3", i)
+	}
+	if v := NodeVoltage(5.0, 1000.0, i); math.Abs(v-3.0) > eps {
+		t.Errorf("NodeVoltage = %v, want 3", v)
+	}
+}
+
+func TestNaturalOffset(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	want := 1010.0/2010.0 - 1000.0/2005.0
+	if got := npp.NaturalOffset(); math.Abs(got-want) > eps {
+		t.Errorf("NaturalOffset = %v, want %v", got, want)
+	}
+	// The bridge itself keeps its balance resistors.
+	if npp.RC != 18.0 || math.Abs(npp.V2mV6) > 1.0e-5 {
+		t.Errorf("NaturalOffset changed the bridge to %+v", npp)
+	}
+}
+
+func TestRABValueAndRCDValue(t *testing.T) {
+	npp := NPP301{RA: 100.0, RA2: 50.0, RB: 150.0, RC: 30.0}
+	if got := npp.RABValue(); math.Abs(got-75.0) > eps {
+		t.Errorf("RABValue = %v, want 75", got)
+	}
+	if got := npp.RCDValue(); got != 30.0 {
+		t.Errorf("RCDValue = %v, want 30", got)
+	}
+	npp.RC = 0.0
+	if got := npp.RCDValue(); got != 0.0 {
+		t.Errorf("RCDValue of a jumpered position = %v, want 0", got)
+	}
+}
+
+func TestSenseLines(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	z2, z6 := npp.OutputImpedance()
+	sensed := npp
+	sensed.RS2, sensed.RS6 = 2.5, 4.0
+	sensed.ComputeUnbalance()
+	if sensed.V2 != npp.V2 || sensed.V6 != npp.V6 {
+		t.Errorf("sense lines changed the outputs to v2=%v v6=%v", sensed.V2, sensed.V6)
+	}
+	if s2, s6 := sensed.OutputImpedance(); math.Abs(s2-z2-2.5) > eps || math.Abs(s6-z6-4.0) > eps {
+		t.Errorf("OutputImpedance with sense lines = %v, %v, want %v, %v", s2, s6, z2+2.5, z6+4.0)
+	}
+}
+
+func TestADCLoading(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	z2, z6 := npp.OutputImpedance()
+	loaded := npp
+	loaded.RADC = 5.0e3
+	loaded.ComputeUnbalance()
+	if want := npp.V2 * 5.0e3 / (5.0e3 + z2); math.Abs(loaded.V2-want) > eps {
+		t.Errorf("loaded v2 = %v, want %v", loaded.V2, want)
+	}
+	if want := npp.V6 * 5.0e3 / (5.0e3 + z6); math.Abs(loaded.V6-want) > eps {
+		t.Errorf("loaded v6 = %v, want %v", loaded.V6, want)
+	}
+	// The ideal balance allows for the loading.
+	bare := NPP301{R1: 1000, R2: 1010, R3: 1005, R4: 1000, RADC: 2.0e3}
+	for _, target := range []float64{0.0, 0.002} {
+		RAB, RCD := bare.IdealBalance(target)
+		c := bare
+		c.RA, c.RC = RAB, RCD
+		c.ComputeUnbalance()
+		if math.Abs(c.V2mV6-target) > eps {
+			t.Errorf("loaded, with RAB=%v RCD=%v, v2-v6 = %v, want %v", RAB, RCD, c.V2mV6, target)
+		}
+	}
+}
+
+func TestTrimRatio(t *testing.T) {
+	npp := NPP301{R1: 1000, R2: 990, R3: 1005, R4: 1000, RL2: 10, RC: 100, RD: 100}
+	if got := npp.TrimRatio(); math.Abs(got-0.05) > eps {
+		t.Errorf("TrimRatio with RCD=50 on R4=1000 = %v, want 0.05", got)
+	}
+	npp.RA, npp.RB = 800, 0
+	if got := npp.TrimRatio(); math.Abs(got-0.8) > eps {
+		t.Errorf("TrimRatio with RAB=800 on R2+RL2=1000 = %v, want 0.8", got)
+	}
+}
+
+// FuzzComputeUnbalance checks the invariants of the bridge equations
+// over arms and balance resistors of any plausible value: the output is
+// finite, a bridge whose legs are alike has no offset, and swapping the
+// legs negates the offset.  Run it with go test -fuzz=FuzzComputeUnbalance.
+func FuzzComputeUnbalance(f *testing.F) {
+	f.Add(1000.0, 1010.0, 1005.0, 1000.0, 0.0, 0.0, 18.0, 91.0, 1.0)
+	f.Add(1010.0, 1000.0, 1000.0, 1005.0, 18.0, 91.0, 0.0, 0.0, -3.3)
+	f.Add(5.0e3, 4.7e3, 5.1e3, 4.9e3, 220.0, 0.0, 1.0e3, 2.2e3, 5.0)
+	f.Fuzz(func(t *testing.T, R1, R2, R3, R4, RA, RB, RC, RD, vexc float64) {
+		// Arms from a milliohm to 10 Mohm, balance resistors unfitted or
+		// up to 10 Mohm, and a nonzero excitation of up to 100 V.
+		for _, R := range []float64{R1, R2, R3, R4} {
+			if !(R >= 1.0e-3 && R <= 1.0e7) {
+				t.Skip()
+			}
+		}
+		for _, R := range []float64{RA, RB, RC, RD} {
+			if !(R >= 0.0 && R <= 1.0e7) {
+				t.Skip()
+			}
+		}
+		if !(math.Abs(vexc) >= 1.0e-3 && math.Abs(vexc) <= 100.0) {
+			t.Skip()
+		}
+		npp := NPP301{R1: R1, R2: R2, R3: R3, R4: R4, RA: RA, RB: RB, RC: RC, RD: RD, Vexc: vexc}
+		npp.ComputeUnbalance()
+		for _, v := range []float64{npp.V2mV6, npp.V2, npp.V6, npp.I12, npp.I34} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				t.Fatalf("%+v: output is not finite", npp)
+			}
+		}
+		swapped := NPP301{R1: R3, R2: R4, R3: R1, R4: R2, RA: RC, RB: RD, RC: RA, RD: RB, Vexc: vexc}
+		swapped.ComputeUnbalance()
+		if swapped.V2mV6 != -npp.V2mV6 {
+			t.Errorf("%+v: with the legs swapped, v2-v6 = %v, want %v", npp, swapped.V2mV6, -npp.V2mV6)
+		}
+		alike := NPP301{R1: R1, R2: R2, R3: R1, R4: R2, RA: RA, RB: RB, RC: RA, RD: RB, Vexc: vexc}
+		alike.ComputeUnbalance()
+		if alike.V2mV6 != 0.0 {
+			t.Errorf("%+v: with the legs alike, v2-v6 = %v, want 0", alike, alike.V2mV6)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+// power.go
+// Power dissipated in the resistors of the bridge.
+
+package npp301
+
+import (
+	"fmt"
+	"math"
+)
+
+// Power holds the dissipation, in watts, in each resistor of the bridge.
+type Power struct {
+	R1, R2, R3, R4 float64
+	RA, RB, RC, RD float64
+	RA2, RC2       float64
+}
+
+// Dissipation returns the power in each resistor, from the leg currents
+// found by ComputeUnbalance.  Unfitted balance resistors dissipate nothing.
+func (bridge *NPP301) Dissipation() Power {
+	i12, i34 := bridge.I12, bridge.I34
+	// The pair of balance resistors on each leg share the voltage across them.
+	vAB := i12 * bridge.RABValue()
+	vCD := i34 * bridge.RCDValue()
+	// A resistor of a pair may have a second resistor, R2, in series.
+	inPair := func(v, R, R2 float64) float64 {
+		if R+R2 == 0.0 {
+			return 0.0
+		}
+		i := v / (R + R2)
+		return i * i * R
+	}
+	return Power{
+		R1:  i12 * i12 * bridge.R1,
+		R2:  i12 * i12 * bridge.R2,
+		R3:  i34 * i34 * bridge.R3,
+		R4:  i34 * i34 * bridge.R4,
+		RA:  inPair(vAB, bridge.RA, bridge.RA2),
+		RA2: inPair(vAB, bridge.RA2, bridge.RA),
+		RB:  inPair(vAB, bridge.RB, 0.0),
+		RC:  inPair(vCD, bridge.RC, bridge.RC2),
+		RC2: inPair(vCD, bridge.RC2, bridge.RC),
+		RD:  inPair(vCD, bridge.RD, 0.0),
+	}
+}
+
+// MaxBalance returns the largest dissipation among the balance resistors.
+func (p Power) MaxBalance() float64 {
+	return math.Max(math.Max(math.Max(p.RA, p.RB), math.Max(p.RC, p.RD)), math.Max(p.RA2, p.RC2))
+}
+
+// TotalCurrent returns the magnitude of the current drawn from the
+// excitation, the sum of the leg currents found by ComputeUnbalance.
+// It is largest without balance resistors, which only add resistance.
+func (bridge *NPP301) TotalCurrent() float64 {
+	return math.Abs(bridge.I12 + bridge.I34)
+}
+
+// CheckCurrent returns an error if the bridge, without balance resistors,
+// draws more than limit amps from the excitation, as would overheat
+// a real sensor.  A zero limit disables the check.
+func (bridge *NPP301) CheckCurrent(limit float64) error {
+	if limit == 0.0 {
+		return nil
+	}
+	npp := bridge.Bare()
+	if i := npp.TotalCurrent(); i > limit {
+		return fmt.Errorf("bridge draws %.3g A from the excitation, above the limit of %.3g A", i, limit)
+	}
+	return nil
+}
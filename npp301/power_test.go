@@ -0,0 +1,50 @@
+package npp301
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDissipationBalancesSupply(t *testing.T) {
+	npp := testBridge
+	npp.Vexc = 5.0
+	npp.RA, npp.RC, npp.RD = 47.0, 18.0, 91.0
+	npp.ComputeUnbalance()
+	p := npp.Dissipation()
+	total := p.R1 + p.R2 + p.R3 + p.R4 + p.RA + p.RB + p.RC + p.RD
+	supply := npp.Vexc * (npp.I12 + npp.I34)
+	if math.Abs(total-supply) > eps {
+		t.Errorf("total dissipation %v W, want the supplied %v W", total, supply)
+	}
+	if p.RB != 0.0 {
+		t.Errorf("unfitted RB dissipates %v W", p.RB)
+	}
+	if p.MaxBalance() != math.Max(p.RA, math.Max(p.RC, p.RD)) {
+		t.Errorf("MaxBalance = %v", p.MaxBalance())
+	}
+}
+
+func TestCheckCurrent(t *testing.T) {
+	npp := testBridge
+	npp.Vexc = 5.0
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	want := 5.0/(1000.0+1010.0) + 5.0/(1005.0+1000.0+npp.RCDValue())
+	if got := npp.TotalCurrent(); math.Abs(got-want) > eps {
+		t.Errorf("TotalCurrent = %v, want %v", got, want)
+	}
+	// The check is made without the balance resistors, 5 mA in all.
+	if err := npp.CheckCurrent(0.01); err != nil {
+		t.Errorf("unexpected error at a 10 mA limit: %v", err)
+	}
+	if err := npp.CheckCurrent(0.004); err == nil {
+		t.Error("expected an error at a 4 mA limit")
+	}
+	if err := npp.CheckCurrent(0.0); err != nil {
+		t.Errorf("a zero limit should disable the check, got %v", err)
+	}
+	small := NPP301{R1: 1.0, R2: 1.0, R3: 1.0, R4: 1.0, Vexc: -10.0}
+	if err := small.CheckCurrent(1.0); err == nil {
+		t.Error("expected an error for a reversed 10 V across 1 ohm arms")
+	}
+}
@@ -0,0 +1,57 @@
+// resolution.go
+// The finest step in output that the resistor values can make.
+
+package npp301
+
+import (
+	"sort"
+)
+
+// resolutionWindow bounds the balance resistances sampled by Resolution
+// to within this factor of the ideal balance resistance.
+const resolutionWindow = 2.0
+
+// resolutionNeighbours is the number of achievable outputs on each side
+// of the target over which Resolution takes the spacing.
+const resolutionNeighbours = 10
+
+// Resolution estimates the step in v2-v6, in volts, between adjacent
+// achievable outputs of the search with these options near the target,
+// so that a realistic tolerance can be chosen before searching.
+// Only the ideal leg is sampled, over the pairs of values within
+// resolutionWindow of the ideal balance resistance, and BothLegs is
+// ignored.  The step is the mean spacing of the resolutionNeighbours
+// outputs on each side of the target; the smallest single gap means
+// little, as two pairs may happen to give nearly the same resistance.
+// It returns zero for a bridge that needs no balance resistance,
+// or when the outputs sampled do not straddle the target.
+func (bridge *NPP301) Resolution(opts SearchOptions) float64 {
+	npp := bridge.Bare()
+	RAB, RCD := npp.IdealBalance(opts.Target)
+	ideal := RAB + RCD
+	if !(ideal > 0.0) {
+		return 0.0
+	}
+	leg34 := npp.AdjustsLeg34(opts.Target)
+	values := opts.valuesAB()
+	if leg34 {
+		values = opts.valuesCD()
+	}
+	// Neither of a pair can be below the resistance it makes.
+	values = ValuesInRange(values, ideal/resolutionWindow, 0.0)
+	leg := newLegOutput(&npp, leg34)
+	var outputs []float64
+	for _, pair := range balancePairs(values, opts) {
+		if pair.R > ideal*resolutionWindow {
+			continue
+		}
+		outputs = append(outputs, leg.at(pair.R))
+	}
+	sort.Float64s(outputs)
+	k := sort.SearchFloat64s(outputs, opts.Target)
+	if k == 0 || k == len(outputs) {
+		return 0.0
+	}
+	lo, hi := max(k-resolutionNeighbours, 0), min(k+resolutionNeighbours, len(outputs))
+	return (outputs[hi-1] - outputs[lo]) / float64(hi-1-lo)
+}
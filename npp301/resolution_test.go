@@ -0,0 +1,20 @@
+package npp301
+
+import (
+	"testing"
+)
+
+func TestResolution(t *testing.T) {
+	coarse := testBridge.Resolution(SearchOptions{ValuesCD: SeriesValues(E12, DefaultMinExp, DefaultMaxExp)})
+	fine := testBridge.Resolution(SearchOptions{ValuesCD: SeriesValues(E96, DefaultMinExp, DefaultMaxExp)})
+	if !(coarse > 0.0) || !(fine > 0.0) {
+		t.Fatalf("expected positive steps, got %v for E12 and %v for E96", coarse, fine)
+	}
+	if fine >= coarse {
+		t.Errorf("E96 step %v is not finer than the E12 step %v", fine, coarse)
+	}
+	balanced := NPP301{R1: 1000.0, R2: 1000.0, R3: 1000.0, R4: 1000.0}
+	if got := balanced.Resolution(SearchOptions{}); got != 0.0 {
+		t.Errorf("Resolution of a balanced bridge = %v, want 0", got)
+	}
+}
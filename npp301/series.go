@@ -0,0 +1,179 @@
+// series.go
+// The standard IEC 60063 resistor series.
+
+package npp301
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The base series hold the significant digits of each value in one decade.
+// E12 and E24 have two significant figures, the finer series have three.
+
+// E12 is the base of the 10% series.
+var E12 = []float64{
+	10, 12, 15, 18, 22, 27, 33, 39, 47, 56, 68, 82,
+}
+
+// E24 is the base of the 5% series.
+var E24 = []float64{
+	10, 11, 12, 13, 15, 16, 18, 20, 22, 24, 27, 30,
+	33, 36, 39, 43, 47, 51, 56, 62, 68, 75, 82, 91,
+}
+
+// E48 is the base of the 2% series.
+var E48 = []float64{
+	100, 105, 110, 115, 121, 127, 133, 140, 147, 154, 162, 169,
+	178, 187, 196, 205, 215, 226, 237, 249, 261, 274, 287, 301,
+	316, 332, 348, 365, 383, 402, 422, 442, 464, 487, 511, 536,
+	562, 590, 619, 649, 681, 715, 750, 787, 825, 866, 909, 953,
+}
+
+// E96 is the base of the 1% series.
+var E96 = []float64{
+	100, 102, 105, 107, 110, 113, 115, 118, 121, 124, 127, 130,
+	133, 137, 140, 143, 147, 150, 154, 158, 162, 165, 169, 174,
+	178, 182, 187, 191, 196, 200, 205, 210, 215, 221, 226, 232,
+	237, 243, 249, 255, 261, 267, 274, 280, 287, 294, 301, 309,
+	316, 324, 332, 340, 348, 357, 365, 374, 383, 392, 402, 412,
+	422, 432, 442, 453, 464, 475, 487, 499, 511, 523, 536, 549,
+	562, 576, 590, 604, 619, 634, 649, 665, 681, 698, 715, 732,
+	750, 768, 787, 806, 825, 845, 866, 887, 909, 931, 953, 976,
+}
+
+// E192 is the base of the 0.5% series.
+// Note that 920 is the accepted value, rather than the computed 919.
+var E192 = []float64{
+	100, 101, 102, 104, 105, 106, 107, 109, 110, 111, 113, 114,
+	115, 117, 118, 120, 121, 123, 124, 126, 127, 129, 130, 132,
+	133, 135, 137, 138, 140, 142, 143, 145, 147, 149, 150, 152,
+	154, 156, 158, 160, 162, 164, 165, 167, 169, 172, 174, 176,
+	178, 180, 182, 184, 187, 189, 191, 193, 196, 198, 200, 203,
+	205, 208, 210, 213, 215, 218, 221, 223, 226, 229, 232, 234,
+	237, 240, 243, 246, 249, 252, 255, 258, 261, 264, 267, 271,
+	274, 277, 280, 284, 287, 291, 294, 298, 301, 305, 309, 312,
+	316, 320, 324, 328, 332, 336, 340, 344, 348, 352, 357, 361,
+	365, 370, 374, 379, 383, 388, 392, 397, 402, 407, 412, 417,
+	422, 427, 432, 437, 442, 448, 453, 459, 464, 470, 475, 481,
+	487, 493, 499, 505, 511, 517, 523, 530, 536, 542, 549, 556,
+	562, 569, 576, 583, 590, 597, 604, 612, 619, 626, 634, 642,
+	649, 657, 665, 673, 681, 690, 698, 706, 715, 723, 732, 741,
+	750, 759, 768, 777, 787, 796, 806, 816, 825, 835, 845, 856,
+	866, 876, 887, 898, 909, 920, 931, 942, 953, 965, 976, 988,
+}
+
+// seriesByName maps the usual names to the base series.
+var seriesByName = map[string][]float64{
+	"E12": E12, "E24": E24, "E48": E48, "E96": E96, "E192": E192,
+}
+
+// Default decade range for the generated resistor values,
+// 1 ohm to 91k ohm in the E24 series.
+const (
+	DefaultMinExp = 0
+	DefaultMaxExp = 4
+)
+
+// SeriesValues tiles the base series across the decades 10^minExp to 10^maxExp.
+// The first value of each decade is exactly the power of ten,
+// so SeriesValues(E24, -1, 5) runs from 0.1 ohm to 910k ohm.
+func SeriesValues(base []float64, minExp, maxExp int) []float64 {
+	// The first value of the base series becomes 10^exp ohms.
+	figures := int(math.Round(math.Log10(base[0])))
+	var values []float64
+	for exp := minExp; exp <= maxExp; exp++ {
+		shift := exp - figures
+		for _, digits := range base {
+			// Multiply or divide by exact powers of ten so that the values
+			// come out exactly as they would be written as literals.
+			if shift >= 0 {
+				values = append(values, digits*math.Pow10(shift))
+			} else {
+				values = append(values, digits/math.Pow10(-shift))
+			}
+		}
+	}
+	return values
+}
+
+// Series returns the resistor values for the named series, E12 to E192,
+// over the decades from 1 ohm up to the last value below 100k ohm,
+// such as 91k for E24.
+func Series(name string) ([]float64, error) {
+	return SeriesRange(name, DefaultMinExp, DefaultMaxExp)
+}
+
+// SeriesRange returns the resistor values for the named series
+// over the decades 10^minExp to 10^maxExp.
+func SeriesRange(name string, minExp, maxExp int) ([]float64, error) {
+	base, ok := seriesByName[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown resistor series %q", name)
+	}
+	if minExp > maxExp {
+		return nil, fmt.Errorf("decade range %d to %d is empty", minExp, maxExp)
+	}
+	return SeriesValues(base, minExp, maxExp), nil
+}
+
+// ValuesInRange returns the values from Rmin to Rmax inclusive,
+// a zero limit leaving that end open, such as to avoid parts too
+// small for the current or too large for the noise.
+func ValuesInRange(values []float64, Rmin, Rmax float64) []float64 {
+	var inRange []float64
+	for _, value := range values {
+		if value < Rmin || (Rmax != 0.0 && value > Rmax) {
+			continue
+		}
+		inRange = append(inRange, value)
+	}
+	return inRange
+}
+
+// ReadValues reads a set of resistor values, one resistance in ohms
+// per line, such as the stock on hand, for use in place of a series.
+// Blank lines and lines starting with # are skipped.  The values are
+// returned in increasing order, without repeats, as the search expects.
+// Every line that is not a positive resistance is reported in the error.
+func ReadValues(r io.Reader) ([]float64, error) {
+	var values []float64
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || !(value > 0.0) || math.IsInf(value, 1) {
+			errs = append(errs, fmt.Errorf("line %d: expected a positive resistance, got %q", line, text))
+			continue
+		}
+		values = append(values, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no resistor values found")
+	}
+	sort.Float64s(values)
+	n := 1
+	for _, value := range values[1:] {
+		if value != values[n-1] {
+			values[n] = value
+			n++
+		}
+	}
+	return values[:n], nil
+}
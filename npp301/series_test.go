@@ -0,0 +1,64 @@
+package npp301
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSeriesLengths(t *testing.T) {
+	for name, perDecade := range map[string]int{"E12": 12, "E24": 24, "E48": 48, "E96": 96, "E192": 192} {
+		values, err := SeriesRange(name, -1, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(values) != 7*perDecade {
+			t.Errorf("%s: got %d values, want %d", name, len(values), 7*perDecade)
+		}
+		if values[0] != 0.1 || values[perDecade] != 1.0 || values[6*perDecade] != 1.0e5 {
+			t.Errorf("%s: decades start at %v, %v and %v", name, values[0], values[perDecade], values[6*perDecade])
+		}
+		for i := 1; i < len(values); i++ {
+			if values[i] <= values[i-1] {
+				t.Fatalf("%s: values not increasing at %v", name, values[i])
+			}
+		}
+	}
+}
+
+func TestDefaultRvalues(t *testing.T) {
+	// The original hand-typed table ran from 1 ohm to 91k ohm in E24.
+	if len(Rvalues) != 120 || Rvalues[0] != 1.0 || Rvalues[len(Rvalues)-1] != 91.0e3 {
+		t.Errorf("Rvalues has %d values from %v to %v", len(Rvalues), Rvalues[0], Rvalues[len(Rvalues)-1])
+	}
+	if Rvalues[16] != 4.7 || Rvalues[40] != 47.0 {
+		t.Errorf("Rvalues[16] = %v and Rvalues[40] = %v, want 4.7 and 47", Rvalues[16], Rvalues[40])
+	}
+	if _, err := SeriesRange("E7", 0, 4); err == nil {
+		t.Error("expected an error for an unknown series")
+	}
+}
+
+func TestReadValues(t *testing.T) {
+	values, err := ReadValues(strings.NewReader("# on hand\n47\n10\n\n 2.2e3 \n10\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{10, 47, 2200}; !reflect.DeepEqual(values, want) {
+		t.Errorf("ReadValues = %v, want %v", values, want)
+	}
+	_, err = ReadValues(strings.NewReader("10\nten\n-5\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected errors for lines 2 and 3, got %v", err)
+	}
+}
+
+func TestValuesInRange(t *testing.T) {
+	values := ValuesInRange(Rvalues, 10.0, 1.0e3)
+	if len(values) != 49 || values[0] != 10.0 || values[len(values)-1] != 1.0e3 {
+		t.Errorf("ValuesInRange(10, 1k) has %d values from %v to %v", len(values), values[0], values[len(values)-1])
+	}
+	if values := ValuesInRange(Rvalues, 0.0, 0.0); len(values) != len(Rvalues) {
+		t.Errorf("without limits, ValuesInRange kept %d of %d values", len(values), len(Rvalues))
+	}
+}
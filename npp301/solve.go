@@ -0,0 +1,692 @@
+// solve.go
+// Search the available resistor values for balance resistors.
+
+package npp301
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// SearchOptions adjust the candidate search done by SolveWith.
+// Each parallel pair is tried once only, with RA <= RB and RC <= RD.
+// The zero value gives the search done by Solve.
+type SearchOptions struct {
+	// Single also tries a single balance resistor on the adjusted leg,
+	// leaving the second position of the pair unfitted.
+	Single bool
+	// SeriesPairs also tries a single balance position made up of
+	// two values in series, such as 1.0k + 220.  The number of
+	// elements in series is capped at two to bound the search.
+	SeriesPairs bool
+	// BothLegs also tries balance resistors on both legs together.
+	BothLegs bool
+	// HalfBridge treats R3 and R4 as the fixed reference resistors of
+	// a half-bridge sensor, so that only RA and RB, on the sensing leg
+	// 1-2, are trimmed; BothLegs is then ignored.  The equations are
+	// those of the full bridge:
+	//   v2 = vexc*(R2+RAB)/(R1+R2+RAB),  v6 = vexc*R4/(R3+R4),
+	// and the output is v2-v6, the sensing leg against the reference.
+	// Resistance on leg 1-2 can only raise v2, for a positive
+	// excitation, so a sensor whose v2 is above the reference plus
+	// Target has no candidates and nothing is tried.
+	HalfBridge bool
+	// Symmetric tries only matched pairs, RA equal to RB and RC equal
+	// to RD, so that each leg is fitted with two of the same part at
+	// half its value.  Single and SeriesPairs are then ignored.
+	Symmetric bool
+	// WorstCaseTolPct, when nonzero, also requires that the candidate
+	// stays within tolerance with its balance resistors anywhere
+	// within this percent tolerance.
+	WorstCaseTolPct float64
+	// JitterTolPct, when nonzero, also requires that the candidate stays
+	// within tolerance with its balance resistors moved to the plausible
+	// real values, within this percent tolerance, given by Jittered.
+	JitterTolPct float64
+	// Excitations, if not empty, also requires that the candidate stays
+	// within tolerance when the bridge is run at each of these excitation
+	// voltages, for a design that switches between them.
+	Excitations []float64
+	// MaxTrim and MinTrim, when nonzero, bound the balance resistance,
+	// RAB or RCD, of each leg that is fitted, in ohms, so as to keep
+	// the trim gentle.
+	MaxTrim, MinTrim float64
+	// Target is the bridge output sought, in volts, for a bridge that
+	// is deliberately offset.  Candidates must be within unbalanceTol
+	// of the target, rather than of zero.
+	Target float64
+	// Exhaustive searches even when the bridge, without balance
+	// resistors, is already within tolerance, rather than returning it
+	// as the only candidate, such as to bin the offsets of every
+	// combination with an infinite tolerance.
+	Exhaustive bool
+	// Workers is the number of goroutines sharing the search.
+	// Zero uses one per CPU; one gives a serial search.
+	Workers int
+	// Progress, if not nil, is called as each row of the search
+	// is done, with the count of rows done and the total.
+	// The calls are made one at a time, from the worker goroutines.
+	Progress func(done, total int)
+	// Emit, if not nil, is given each candidate as it is found,
+	// in place of collecting the candidates, so that the memory
+	// used stays bounded for a large search.  The candidates then
+	// come in no particular order and are not sorted.
+	// The calls are made one at a time, from the worker goroutines.
+	Emit func(c NPP301)
+	// ValuesAB and ValuesCD, if not nil, are the resistor values,
+	// in increasing order, for the balance resistors on leg 1-2 and
+	// on leg 3-4, in place of Rvalues, such as for separate bins
+	// of parts for fine and coarse trim.
+	ValuesAB, ValuesCD []float64
+
+	// stop, if set, ends the search early, the rows not yet
+	// started being skipped, as asked for through SolveFunc.
+	stop *atomic.Bool
+}
+
+// stopped reports whether the search has been asked to stop.
+func (opts *SearchOptions) stopped() bool {
+	return opts.stop != nil && opts.stop.Load()
+}
+
+// valuesAB returns the resistor values for RA and RB.
+func (opts *SearchOptions) valuesAB() []float64 {
+	if opts.ValuesAB != nil {
+		return opts.ValuesAB
+	}
+	return Rvalues
+}
+
+// valuesCD returns the resistor values for RC and RD.
+func (opts *SearchOptions) valuesCD() []float64 {
+	if opts.ValuesCD != nil {
+		return opts.ValuesCD
+	}
+	return Rvalues
+}
+
+// Passes reports whether a combination of balance resistors,
+// with its output computed, makes the cut of a search with these options.
+func (opts *SearchOptions) Passes(npp *NPP301, unbalanceTol float64) bool {
+	return math.Abs(npp.V2mV6-opts.Target) < unbalanceTol && opts.accept(npp, unbalanceTol)
+}
+
+// accept applies the checks, beyond the nominal output being within
+// tolerance, that a candidate must pass.
+func (opts *SearchOptions) accept(npp *NPP301, unbalanceTol float64) bool {
+	if opts.WorstCaseTolPct != 0.0 && npp.WorstCaseFrom(opts.Target, opts.WorstCaseTolPct) >= unbalanceTol {
+		return false
+	}
+	if opts.JitterTolPct != 0.0 {
+		jittered := npp.Jittered(opts.JitterTolPct)
+		if math.Abs(jittered.V2mV6-opts.Target) >= unbalanceTol {
+			return false
+		}
+	}
+	for _, R := range []float64{npp.RABValue(), npp.RCDValue()} {
+		if R == 0.0 {
+			continue
+		}
+		if (opts.MaxTrim != 0.0 && R > opts.MaxTrim) || (opts.MinTrim != 0.0 && R < opts.MinTrim) {
+			return false
+		}
+	}
+	for _, vexc := range opts.Excitations {
+		at := npp.WithExcitation(vexc)
+		if math.Abs(at.V2mV6-opts.Target) >= unbalanceTol {
+			return false
+		}
+	}
+	return true
+}
+
+// Solve searches the available resistor values for balance resistors
+// that bring the bridge within unbalanceTol of zero output.
+// unbalanceTol is in volts, the same units as the excitation.
+// The measured arm resistances R1..R4 are taken from the bridge;
+// any balance resistors already set on the bridge are ignored.
+// The candidate solutions are returned with the most balanced first.
+func (bridge *NPP301) Solve(unbalanceTol float64) []NPP301 {
+	return bridge.SolveWith(unbalanceTol, SearchOptions{})
+}
+
+// SolveWith is Solve with the search adjusted by opts.
+func (bridge *NPP301) SolveWith(unbalanceTol float64, opts SearchOptions) []NPP301 {
+	return bridge.Search(unbalanceTol, opts).Candidates
+}
+
+// SolveFunc searches as Solve does, but calls fn with each candidate
+// as it is found, in place of collecting them, so that the memory used
+// stays bounded.  The candidates come in no particular order, one call
+// at a time.  Returning false from fn stops the search early, and fn
+// is not called again.
+func (bridge *NPP301) SolveFunc(unbalanceTol float64, fn func(c NPP301) bool) {
+	bridge.SolveFuncWith(unbalanceTol, SearchOptions{}, fn)
+}
+
+// SolveFuncWith is SolveFunc with the search adjusted by opts,
+// whose Emit is replaced by fn.
+func (bridge *NPP301) SolveFuncWith(unbalanceTol float64, opts SearchOptions, fn func(c NPP301) bool) {
+	opts.stop = new(atomic.Bool)
+	opts.Emit = func(c NPP301) {
+		if !opts.stop.Load() && !fn(c) {
+			opts.stop.Store(true)
+		}
+	}
+	bridge.Search(unbalanceTol, opts)
+}
+
+// SearchResult is the outcome of a search for balance resistors.
+type SearchResult struct {
+	// Candidates are those within tolerance, the most balanced first.
+	Candidates []NPP301
+	// Closest is the combination tried that came closest to the target,
+	// whether or not it is within tolerance.
+	Closest NPP301
+	// Tried is the number of combinations checked against the tolerance.
+	Tried int
+	// AlreadyBalanced is set when the bridge, without balance resistors,
+	// is already within tolerance of the target, so no search was made.
+	// The bridge itself is then the only candidate.
+	AlreadyBalanced bool
+}
+
+// Search is SolveWith, also reporting the closest combination tried.
+func (bridge *NPP301) Search(unbalanceTol float64, opts SearchOptions) SearchResult {
+	// The initial unbalance is just v2-v6 with zero-value resistors applied.
+	npp := bridge.Bare()
+	unbalance := npp.V2mV6
+	// An exact balance counts, even for a zero tolerance.
+	if !opts.Exhaustive && (unbalance == opts.Target || opts.Passes(&npp, unbalanceTol)) {
+		if opts.Emit != nil {
+			opts.Emit(npp)
+			return SearchResult{Closest: npp, Tried: 1, AlreadyBalanced: true}
+		}
+		return SearchResult{Candidates: []NPP301{npp}, Closest: npp, Tried: 1, AlreadyBalanced: true}
+	}
+	if emit := opts.Emit; emit != nil {
+		var mu sync.Mutex
+		opts.Emit = func(c NPP301) {
+			mu.Lock()
+			defer mu.Unlock()
+			emit(c)
+		}
+	}
+	// Each row of the search has one value of the outer resistor,
+	// from the values for the leg that is adjusted.
+	leg34 := npp.AdjustsLeg34(opts.Target)
+	if opts.HalfBridge {
+		if leg34 {
+			return SearchResult{}
+		}
+		opts.BothLegs = false
+	}
+	values := opts.valuesAB()
+	if leg34 {
+		// We set RA=RB=0.0 and check our options for the RC and RD
+		values = opts.valuesCD()
+	}
+	leg := newLegOutput(&npp, leg34)
+	// Resistors in parallel add as conductances, worked out once for
+	// the search rather than for each pair.
+	g := conductances(values)
+	row := func(i int) *rowResult {
+		r := newRowResult(unbalanceTol, &opts)
+		if opts.stopped() {
+			return r
+		}
+		R := values[i]
+		if opts.Symmetric {
+			r.tryOnLeg(&npp, &leg, R, 0.0, R, 1.0/(g[i]+g[i]))
+			return r
+		}
+		if opts.Single {
+			r.tryOnLeg(&npp, &leg, R, 0.0, 0.0, R)
+		}
+		if opts.SeriesPairs {
+			// The larger value is named first, the second being the smaller.
+			r.walk(&npp, &leg, len(values)-i, func(k int) (float64, float64, float64, float64) {
+				Rs := values[i+k]
+				return Rs, R, 0.0, Rs + R
+			})
+		}
+		// The pair (Rp, R) with Rp < R is electrically the same,
+		// so the second of the pair is drawn from values[i:].
+		r.walk(&npp, &leg, len(values)-i, func(k int) (float64, float64, float64, float64) {
+			return R, 0.0, values[i+k], 1.0 / (g[i] + g[i+k])
+		})
+		return r
+	}
+	var pairsAB, pairsCD []balancePair
+	if opts.BothLegs {
+		pairsAB = balancePairs(opts.valuesAB(), opts)
+		pairsCD = balancePairs(opts.valuesCD(), opts)
+	}
+	p := &progress{report: opts.Progress, total: len(values) + len(pairsAB)}
+	results := searchRows(len(values), opts.Workers, row, p)
+	if opts.BothLegs {
+		results = append(results, npp.searchBothLegs(pairsAB, pairsCD, unbalanceTol, opts, p)...)
+	}
+	result := mergeRows(results, opts.Target)
+	SortCandidatesTo(result.Candidates, opts.Target)
+	return result
+}
+
+// rowResult gathers what one row of the search found.
+type rowResult struct {
+	unbalanceTol float64
+	opts         *SearchOptions
+	candidates   []NPP301
+	closest      NPP301
+	tried        int
+}
+
+func newRowResult(unbalanceTol float64, opts *SearchOptions) *rowResult {
+	return &rowResult{unbalanceTol: unbalanceTol, opts: opts}
+}
+
+// legOutput gives v2-v6 for a balance resistance on the adjusted leg,
+// the rest of the bridge being fixed.  The parts that do not depend
+// on the balance resistance are worked out once, and the arithmetic
+// is that of ComputeUnbalance, so the result is the same to the bit.
+type legOutput struct {
+	leg34      bool
+	vexc       float64
+	rArms      float64 // the two arms of the adjusted leg in series
+	rTop       float64 // the arm from the supply to the output pin
+	rBottom    float64 // the arm below the output pin
+	rSense     float64 // the sense line from the output pin
+	rADC       float64 // the ADC input loading the output pin
+	vOtherSide float64 // the output pin of the leg that is not adjusted
+}
+
+// newLegOutput sets up the leg model for a bridge without balance resistors.
+func newLegOutput(npp *NPP301, leg34 bool) legOutput {
+	R1, R2, R3, R4 := npp.arms()
+	if leg34 {
+		return legOutput{leg34, npp.Excitation(), R3 + R4, R3, R4, npp.RS6, npp.RADC, npp.V2}
+	}
+	return legOutput{leg34, npp.Excitation(), R1 + R2, R1, R2, npp.RS2, npp.RADC, npp.V6}
+}
+
+// at returns v2-v6 with the balance resistance R on the adjusted leg.
+func (leg *legOutput) at(R float64) float64 {
+	i := leg.vexc / (leg.rArms + R)
+	v := leg.vexc - leg.rTop*i
+	if leg.rADC != 0.0 {
+		v = LoadedVoltage(v, ParallelR(leg.rTop, leg.rBottom+R)+leg.rSense, leg.rADC)
+	}
+	if leg.leg34 {
+		return leg.vOtherSide - v
+	}
+	return v - leg.vOtherSide
+}
+
+// solve returns the balance resistance that brings the output to target,
+// found by bisection as the output moves steadily one way with R.
+// The bracket grows from the arms of the leg until it holds the target;
+// a target out of reach of any resistance gives +Inf.
+func (leg *legOutput) solve(target float64) float64 {
+	below := leg.at(0.0) < target
+	lo, hi := 0.0, leg.rArms
+	for (leg.at(hi) < target) == below {
+		if math.IsInf(hi, 1) {
+			return hi
+		}
+		lo, hi = hi, 2.0*hi
+	}
+	for n := 0; n < 200; n++ {
+		mid := 0.5 * (lo + hi)
+		if mid == lo || mid == hi {
+			break
+		}
+		if (leg.at(mid) < target) == below {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return 0.5 * (lo + hi)
+}
+
+// tryOnLeg tries the balance resistors R in series with R2, in parallel
+// with Rp, making the resistance Rc, on the adjusted leg of the bridge
+// npp, and reports whether its nominal output is within tolerance.
+// The output is found from the leg model and the full bridge is only
+// worked out for a combination that could be kept, as a candidate or
+// as the closest.  Rc must be ParallelR(R+R2, Rp) to the bit, for the
+// leg model to agree with ComputeUnbalance.
+func (r *rowResult) tryOnLeg(npp *NPP301, leg *legOutput, R, R2, Rp, Rc float64) bool {
+	d := math.Abs(leg.at(Rc) - r.opts.Target)
+	if r.tried > 0 && d >= r.unbalanceTol && d > math.Abs(r.closest.V2mV6-r.opts.Target) {
+		r.tried++
+		return false
+	}
+	nppTest := *npp
+	if leg.leg34 {
+		nppTest.RC, nppTest.RC2, nppTest.RD = R, R2, Rp
+	} else {
+		nppTest.RA, nppTest.RA2, nppTest.RB = R, R2, Rp
+	}
+	nppTest.ComputeUnbalance()
+	return r.consider(&nppTest)
+}
+
+// walk tries the combinations R in series with R2, in parallel with Rp,
+// making Rc, given by combine for each of the n increasing values on
+// the adjusted leg, by their index.
+// The resistance of the combination, and so the output, moves steadily
+// one way as the value increases, so we find the ideal value by bisection,
+// snap to the values either side of it and walk outward only as far as
+// the candidates stay within tolerance.  The combinations not reached
+// cannot make the cut, nor come closer to the target, and are counted
+// as tried without working them out, so that the result is that of
+// trying every combination, in a time that grows with the number of
+// values rather than with its square.
+func (r *rowResult) walk(npp *NPP301, leg *legOutput, n int, combine func(k int) (float64, float64, float64, float64)) {
+	if n == 0 {
+		return
+	}
+	output := func(k int) float64 {
+		_, _, _, Rc := combine(k)
+		return leg.at(Rc)
+	}
+	rising := output(n-1) > output(0)
+	k0 := sort.Search(n, func(k int) bool {
+		if rising {
+			return output(k) >= r.opts.Target
+		}
+		return output(k) <= r.opts.Target
+	})
+	visited := 0
+	for k := k0; k < n; k++ {
+		visited++
+		if R, R2, Rp, Rc := combine(k); !r.tryOnLeg(npp, leg, R, R2, Rp, Rc) {
+			break
+		}
+	}
+	for k := k0 - 1; k >= 0; k-- {
+		visited++
+		if R, R2, Rp, Rc := combine(k); !r.tryOnLeg(npp, leg, R, R2, Rp, Rc) {
+			break
+		}
+	}
+	r.tried += n - visited
+}
+
+// consider keeps an evaluated combination if it makes the cut,
+// and reports whether its nominal output is within tolerance.
+func (r *rowResult) consider(npp *NPP301) bool {
+	if r.tried == 0 || closer(npp, &r.closest, r.opts.Target) {
+		r.closest = *npp
+	}
+	r.tried++
+	if math.Abs(npp.V2mV6-r.opts.Target) >= r.unbalanceTol {
+		return false
+	}
+	if !r.opts.accept(npp, r.unbalanceTol) {
+		return true
+	}
+	if r.opts.Emit != nil {
+		r.opts.Emit(*npp)
+	} else {
+		r.candidates = append(r.candidates, *npp)
+	}
+	return true
+}
+
+// mergeRows gathers the rows, in order, into a single result.
+func mergeRows(results []*rowResult, target float64) SearchResult {
+	var result SearchResult
+	for _, r := range results {
+		result.Candidates = append(result.Candidates, r.candidates...)
+		if r.tried > 0 && (result.Tried == 0 || closer(&r.closest, &result.Closest, target)) {
+			result.Closest = r.closest
+		}
+		result.Tried += r.tried
+	}
+	return result
+}
+
+// progress counts the rows of a search as they are done.
+type progress struct {
+	mu          sync.Mutex
+	report      func(done, total int)
+	done, total int
+}
+
+// rowDone reports one more row done.
+func (p *progress) rowDone() {
+	if p.report == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.report(p.done, p.total)
+}
+
+// searchRows runs the rows 0..n-1 of a search on a pool of workers.
+// The results are kept in row order so that the outcome
+// is the same as for a serial search, whatever the number of workers.
+func searchRows(n, workers int, row func(i int) *rowResult, p *progress) []*rowResult {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]*rowResult, n)
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				results[i] = row(i)
+				p.rowDone()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+	return results
+}
+
+// balancePair is a parallel pair of balance resistors for one leg.
+// Rb is zero for a single resistor and Ra2 is the second resistor
+// of a series combination.  R is the resistance of the combination.
+type balancePair struct {
+	Ra, Rb, Ra2, R float64
+}
+
+// conductances returns the conductance of each of the resistor values,
+// none of which is zero, so that the resistance of a parallel pair is
+// 1/(g[i]+g[j]), the same to the bit as ParallelR(values[i], values[j]).
+func conductances(values []float64) []float64 {
+	g := make([]float64, len(values))
+	for i, R := range values {
+		g[i] = 1.0 / R
+	}
+	return g
+}
+
+// balancePairs lists the pairs that can be made from the values,
+// ordered by their resistance.
+func balancePairs(values []float64, opts SearchOptions) []balancePair {
+	var pairs []balancePair
+	g := conductances(values)
+	for i, Ra := range values {
+		if opts.Symmetric {
+			pairs = append(pairs, balancePair{Ra, Ra, 0.0, 1.0 / (g[i] + g[i])})
+			continue
+		}
+		if opts.Single {
+			pairs = append(pairs, balancePair{Ra, 0.0, 0.0, Ra})
+		}
+		if opts.SeriesPairs {
+			for _, Rs := range values[i:] {
+				pairs = append(pairs, balancePair{Rs, 0.0, Ra, Rs + Ra})
+			}
+		}
+		for j, Rb := range values {
+			if Rb < Ra {
+				continue
+			}
+			pairs = append(pairs, balancePair{Ra, Rb, 0.0, 1.0 / (g[i] + g[j])})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].R < pairs[j].R })
+	return pairs
+}
+
+// searchBothLegs finds candidates with balance resistors fitted to both legs.
+// Rather than trying every combination of the four resistors,
+// we use the fact that, for a fixed pair on leg 1-2, v2-v6 falls
+// steadily as the resistance on leg 3-4 increases, or rises for
+// a reversed excitation.  A bisection finds where it crosses the
+// target and we then walk outward from there only as far as
+// the candidates stay within tolerance.
+func (bridge *NPP301) searchBothLegs(pairsAB, pairsCD []balancePair, unbalanceTol float64, opts SearchOptions, p *progress) []*rowResult {
+	row := func(i int) *rowResult {
+		r := newRowResult(unbalanceTol, &opts)
+		if opts.stopped() {
+			return r
+		}
+		nppTest := *bridge
+		nppTest.RA, nppTest.RB, nppTest.RA2 = pairsAB[i].Ra, pairsAB[i].Rb, pairsAB[i].Ra2
+		unbalanceAt := func(k int) NPP301 {
+			npp := nppTest
+			npp.RC, npp.RD, npp.RC2 = pairsCD[k].Ra, pairsCD[k].Rb, pairsCD[k].Ra2
+			npp.ComputeUnbalance()
+			return npp
+		}
+		reversed := bridge.Excitation() < 0.0
+		k0 := sort.Search(len(pairsCD), func(k int) bool {
+			npp := unbalanceAt(k)
+			if reversed {
+				return npp.V2mV6 >= opts.Target
+			}
+			return npp.V2mV6 <= opts.Target
+		})
+		for k := k0; k < len(pairsCD); k++ {
+			npp := unbalanceAt(k)
+			if !r.consider(&npp) {
+				break
+			}
+		}
+		for k := k0 - 1; k >= 0; k-- {
+			npp := unbalanceAt(k)
+			if !r.consider(&npp) {
+				break
+			}
+		}
+		return r
+	}
+	return searchRows(len(pairsAB), opts.Workers, row, p)
+}
+
+// Grade labels how far inside the tolerance the output sits:
+// A within a tenth of unbalanceTol of the target, B within half
+// and C otherwise, so that the solid options stand out from the marginal.
+func (bridge *NPP301) Grade(target, unbalanceTol float64) string {
+	ratio := math.Abs(bridge.V2mV6-target) / unbalanceTol
+	switch {
+	case ratio <= 0.1:
+		return "A"
+	case ratio <= 0.5:
+		return "B"
+	}
+	return "C"
+}
+
+// BothLegs reports whether balance resistance is fitted to both legs.
+func (bridge *NPP301) BothLegs() bool {
+	return bridge.RABValue() != 0.0 && bridge.RCDValue() != 0.0
+}
+
+// Fitted returns the number of balance resistors placed on the board.
+func (bridge *NPP301) Fitted() int {
+	n := 0
+	for _, R := range []float64{bridge.RA, bridge.RB, bridge.RC, bridge.RD, bridge.RA2, bridge.RC2} {
+		if R != 0.0 {
+			n++
+		}
+	}
+	return n
+}
+
+// DistinctParts counts the distinct balance resistor values that must be
+// stocked for this candidate.  A value that repeats within the candidate,
+// or that matches one of the arm resistances R1..R4, is not counted again.
+func (bridge *NPP301) DistinctParts() int {
+	seen := map[float64]bool{bridge.R1: true, bridge.R2: true, bridge.R3: true, bridge.R4: true}
+	n := 0
+	for _, R := range []float64{bridge.RA, bridge.RB, bridge.RC, bridge.RD, bridge.RA2, bridge.RC2} {
+		if R != 0.0 && !seen[R] {
+			seen[R] = true
+			n++
+		}
+	}
+	return n
+}
+
+// SortByParts orders the candidates by the number of distinct parts,
+// fewest first, keeping the closeness order among equals.
+func SortByParts(candidates []NPP301) {
+	sortByKey(candidates, func(c *NPP301) float64 { return float64(c.DistinctParts()) })
+}
+
+// SortCandidates orders the candidates by closeness to balance.
+// Ties are broken in favour of fewer fitted resistors and then
+// of larger balance resistance, which draws less current through the bridge.
+func SortCandidates(candidates []NPP301) {
+	SortCandidatesTo(candidates, 0.0)
+}
+
+// SortCandidatesTo orders the candidates by closeness to the target output.
+func SortCandidatesTo(candidates []NPP301, target float64) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return closer(&candidates[i], &candidates[j], target)
+	})
+}
+
+// closer reports whether candidate a is to be preferred to b
+// for the target output, in the order described for SortCandidates.
+func closer(a, b *NPP301, target float64) bool {
+	ua, ub := math.Abs(a.V2mV6-target), math.Abs(b.V2mV6-target)
+	if ua != ub {
+		return ua < ub
+	}
+	if na, nb := a.Fitted(), b.Fitted(); na != nb {
+		return na < nb
+	}
+	return a.RABValue()+a.RCDValue() > b.RABValue()+b.RCDValue()
+}
+
+// byKey sorts candidates on a precomputed key, smallest first.
+type byKey struct {
+	candidates []NPP301
+	keys       []float64
+}
+
+func (b byKey) Len() int           { return len(b.candidates) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.candidates[i], b.candidates[j] = b.candidates[j], b.candidates[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// sortByKey is a stable sort of the candidates on the key,
+// so candidates with equal keys stay in their closeness order.
+func sortByKey(candidates []NPP301, key func(c *NPP301) float64) {
+	keys := make([]float64, len(candidates))
+	for i := range candidates {
+		keys[i] = key(&candidates[i])
+	}
+	sort.Stable(byKey{candidates, keys})
+}
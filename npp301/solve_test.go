@@ -0,0 +1,496 @@
+package npp301
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// testBridge is a typical measured NPP-301, a few ohms out of balance.
+var testBridge = NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0}
+
+func TestSolveParallelMatchesSerial(t *testing.T) {
+	opts := SearchOptions{Single: true, BothLegs: true}
+	serialOpts := opts
+	serialOpts.Workers = 1
+	want := testBridge.SolveWith(1.0e-5, serialOpts)
+	if len(want) == 0 {
+		t.Fatal("expected some candidates from the serial search")
+	}
+	for i := 0; i < 3; i++ {
+		got := testBridge.SolveWith(1.0e-5, opts)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("parallel search gave %d candidates, differing from the %d of the serial search",
+				len(got), len(want))
+		}
+	}
+}
+
+func benchmarkSolve(b *testing.B, workers int) {
+	opts := SearchOptions{BothLegs: true, Workers: workers}
+	for i := 0; i < b.N; i++ {
+		testBridge.SolveWith(1.0e-5, opts)
+	}
+}
+
+func BenchmarkSolveSerial(b *testing.B)   { benchmarkSolve(b, 1) }
+func BenchmarkSolveParallel(b *testing.B) { benchmarkSolve(b, 0) }
+
+// BenchmarkSolveOneLegE96 exercises the inner loop of the usual
+// search, over a wide set of values, on one leg only.
+// Working out only the adjusted leg for each combination took this
+// from about 22 ms to 5 ms per search on a single-core Xeon, and
+// snapping to the ideal second value, rather than trying every one,
+// took it to 0.5 ms.  Working out the parallel pairs from conductances
+// found once for the search saves a further few percent.
+func BenchmarkSolveOneLegE96(b *testing.B) {
+	saved := Rvalues
+	defer func() { Rvalues = saved }()
+	Rvalues = SeriesValues(E96, -1, 5)
+	opts := SearchOptions{Single: true, SeriesPairs: true, Workers: 1}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		testBridge.SolveWith(1.0e-5, opts)
+	}
+}
+
+// bruteForce tries every combination on the adjusted leg, as the search
+// originally did, and returns the candidates and the number tried.
+func bruteForce(bridge NPP301, unbalanceTol float64, opts SearchOptions) ([]NPP301, int) {
+	leg34 := bridge.AdjustsLeg34(opts.Target)
+	var candidates []NPP301
+	tried := 0
+	try := func(R, R2, Rp float64) {
+		npp := bridge
+		if leg34 {
+			npp.RC, npp.RC2, npp.RD = R, R2, Rp
+		} else {
+			npp.RA, npp.RA2, npp.RB = R, R2, Rp
+		}
+		npp.ComputeUnbalance()
+		tried++
+		if opts.Passes(&npp, unbalanceTol) {
+			candidates = append(candidates, npp)
+		}
+	}
+	for i, R := range Rvalues {
+		if opts.Single {
+			try(R, 0.0, 0.0)
+		}
+		for _, R2 := range Rvalues[i:] {
+			if opts.SeriesPairs {
+				try(R2, R, 0.0)
+			}
+			try(R, 0.0, R2)
+		}
+	}
+	SortCandidatesTo(candidates, opts.Target)
+	return candidates, tried
+}
+
+func TestSolveMatchesBruteForce(t *testing.T) {
+	const tol = 2.0e-5
+	for _, tc := range []struct {
+		bridge NPP301
+		opts   SearchOptions
+	}{
+		{testBridge, SearchOptions{}},
+		{testBridge, SearchOptions{Single: true, SeriesPairs: true}},
+		{NPP301{R1: 1010.0, R2: 1000.0, R3: 1000.0, R4: 1005.0}, SearchOptions{Single: true}},
+		{testBridge, SearchOptions{Target: -1.0e-3, SeriesPairs: true}},
+		{NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0, Vexc: -1.0}, SearchOptions{WorstCaseTolPct: 0.01}},
+		{NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0, RS2: 50.0, RS6: 50.0, RADC: 1.0e4}, SearchOptions{SeriesPairs: true}},
+	} {
+		want, tried := bruteForce(tc.bridge, tol, tc.opts)
+		if len(want) == 0 {
+			t.Fatalf("%+v: expected the brute-force search to find candidates", tc.opts)
+		}
+		result := tc.bridge.Search(tol, tc.opts)
+		if result.Tried != tried {
+			t.Errorf("%+v: tried %d, want %d", tc.opts, result.Tried, tried)
+		}
+		if len(result.Candidates) != len(want) {
+			t.Errorf("%+v: got %d candidates, want %d", tc.opts, len(result.Candidates), len(want))
+			continue
+		}
+		for i := range want {
+			if result.Candidates[i] != want[i] {
+				t.Errorf("%+v: candidate %d is %+v, want %+v", tc.opts, i, result.Candidates[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestSolveSeriesPairs(t *testing.T) {
+	const tol = 2.0e-5
+	candidates := testBridge.SolveWith(tol, SearchOptions{SeriesPairs: true})
+	found := false
+	for _, c := range candidates {
+		if c.RC2 == 0.0 {
+			continue
+		}
+		found = true
+		if c.RD != 0.0 || c.RC < c.RC2 {
+			t.Errorf("unexpected series combination RC=%v+%v RD=%v", c.RC, c.RC2, c.RD)
+		}
+		check := c
+		check.RC, check.RC2 = c.RC+c.RC2, 0.0
+		check.ComputeUnbalance()
+		if check.V2mV6 != c.V2mV6 {
+			t.Errorf("series combination RC=%v+%v differs from a single %v", c.RC, c.RC2, check.RC)
+		}
+	}
+	if !found {
+		t.Error("expected some series combinations to balance the test bridge")
+	}
+}
+
+func TestSolveHasNoSymmetricDuplicates(t *testing.T) {
+	candidates := testBridge.SolveWith(1.0e-4, SearchOptions{Single: true, BothLegs: true})
+	seen := map[[6]float64]bool{}
+	for _, c := range candidates {
+		if (c.RB != 0.0 && c.RB < c.RA) || (c.RD != 0.0 && c.RD < c.RC) {
+			t.Errorf("pair not in order: RA=%v RB=%v RC=%v RD=%v", c.RA, c.RB, c.RC, c.RD)
+		}
+		key := [6]float64{c.RA, c.RB, c.RC, c.RD, c.RA2, c.RC2}
+		if seen[key] {
+			t.Errorf("duplicate candidate %v", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestSolveSymmetric(t *testing.T) {
+	const tol = 1.0e-4
+	candidates := testBridge.SolveWith(tol, SearchOptions{Symmetric: true, Single: true, BothLegs: true})
+	if len(candidates) == 0 {
+		t.Fatal("expected matched-pair candidates")
+	}
+	for _, c := range candidates {
+		if c.RA != c.RB || c.RC != c.RD || c.RA2 != 0.0 || c.RC2 != 0.0 {
+			t.Errorf("candidate %+v is not made of matched pairs", c)
+		}
+		if math.Abs(c.V2mV6) >= tol {
+			t.Errorf("candidate output %v is not within %v", c.V2mV6, tol)
+		}
+	}
+	// The pair 30R || 30R gives the 15R needed on leg 3-4.
+	if c := candidates[0]; c.RC != 30.0 || c.RA != 0.0 {
+		t.Errorf("best matched pair is %+v, want RC=RD=30", c)
+	}
+}
+
+func TestSolveHalfBridge(t *testing.T) {
+	const tol = 1.0e-4
+	// v2 sits below the reference v6, so RA/RB can raise it.
+	low := NPP301{R1: 1010.0, R2: 1000.0, R3: 1000.0, R4: 1000.0}
+	candidates := low.SolveWith(tol, SearchOptions{HalfBridge: true, BothLegs: true})
+	if len(candidates) == 0 {
+		t.Fatal("expected candidates for the half-bridge")
+	}
+	for _, c := range candidates {
+		if c.RC != 0.0 || c.RD != 0.0 || c.RA == 0.0 {
+			t.Errorf("candidate %+v trims the reference leg", c)
+		}
+	}
+	// With v2 above the reference, there is nothing that RA/RB can do.
+	result := testBridge.Search(tol, SearchOptions{HalfBridge: true})
+	if result.Tried != 0 || len(result.Candidates) != 0 {
+		t.Errorf("got %d tried and %d candidates, want none", result.Tried, len(result.Candidates))
+	}
+}
+
+func TestDistinctParts(t *testing.T) {
+	tests := []struct {
+		bridge NPP301
+		want   int
+	}{
+		{NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0}, 0},
+		{NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0, RC: 30.0, RD: 30.0}, 1},
+		{NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0, RC: 18.0, RD: 91.0}, 2},
+		{NPP301{R1: 1000.0, R2: 1010.0, R3: 1005.0, R4: 1000.0, RA: 1000.0, RC: 18.0, RD: 18.0}, 1},
+	}
+	for _, tc := range tests {
+		if got := tc.bridge.DistinctParts(); got != tc.want {
+			t.Errorf("DistinctParts of %+v = %d, want %d", tc.bridge, got, tc.want)
+		}
+	}
+}
+
+func TestSolveToTarget(t *testing.T) {
+	const target, tol = 0.01, 1.0e-5
+	candidates := testBridge.SolveWith(tol, SearchOptions{Target: target})
+	if len(candidates) == 0 {
+		t.Fatal("expected candidates for the offset target")
+	}
+	for _, c := range candidates {
+		if math.Abs(c.V2mV6-target) >= tol {
+			t.Errorf("candidate output %v is not within %v of %v", c.V2mV6, tol, target)
+		}
+		// Raising v2 above the natural offset needs resistance on leg 1-2.
+		if c.RA == 0.0 || c.RC != 0.0 {
+			t.Errorf("expected balance resistors on leg 1-2 only, got RA=%v RC=%v", c.RA, c.RC)
+		}
+	}
+}
+
+func TestNegativeExcitation(t *testing.T) {
+	const tol = 1.0e-5
+	reversed := testBridge
+	reversed.Vexc = -1.0
+	reversed.ComputeUnbalance()
+	npp := testBridge
+	npp.ComputeUnbalance()
+	if reversed.V2mV6 != -npp.V2mV6 {
+		t.Errorf("reversed output %v, want %v", reversed.V2mV6, -npp.V2mV6)
+	}
+	// Reversing the excitation scales every node voltage by -1,
+	// so the same leg and the same resistors balance the bridge.
+	if !reversed.AdjustsLeg34(0.0) || !npp.AdjustsLeg34(0.0) {
+		t.Error("expected leg 3-4 to be adjusted for both polarities")
+	}
+	want := testBridge.SolveWith(tol, SearchOptions{})
+	got := reversed.SolveWith(tol, SearchOptions{})
+	if len(got) == 0 || len(got) != len(want) {
+		t.Fatalf("got %d candidates for the reversed bridge, want %d", len(got), len(want))
+	}
+	for i, c := range got {
+		if math.Abs(c.V2mV6) >= tol {
+			t.Errorf("candidate output %v is not within %v of zero", c.V2mV6, tol)
+		}
+		if c.RC != want[i].RC || c.RD != want[i].RD || c.RA != 0.0 {
+			t.Errorf("candidate %d is %+v, want %+v", i, c, want[i])
+		}
+	}
+	// The search over both legs finds the same combinations.
+	want = testBridge.SolveWith(tol, SearchOptions{BothLegs: true})
+	got = reversed.SolveWith(tol, SearchOptions{BothLegs: true})
+	if len(got) != len(want) {
+		t.Errorf("got %d candidates on both legs of the reversed bridge, want %d", len(got), len(want))
+	}
+	// A target offset picks the leg by the direction the output must move.
+	if reversed.AdjustsLeg34(-0.01) {
+		t.Error("expected leg 1-2 to be adjusted to lower a reversed output")
+	}
+	RAB, RCD := reversed.IdealBalance(-0.01)
+	reversed.RB = RAB
+	reversed.ComputeUnbalance()
+	if RCD != 0.0 || math.Abs(reversed.V2mV6+0.01) > 1.0e-12 {
+		t.Errorf("ideal balance RAB=%v RCD=%v gives %v, want -0.01", RAB, RCD, reversed.V2mV6)
+	}
+}
+
+func TestSearchReportsClosest(t *testing.T) {
+	loose := testBridge.Search(1.0e-4, SearchOptions{})
+	tight := testBridge.Search(1.0e-12, SearchOptions{})
+	if len(tight.Candidates) != 0 {
+		t.Fatalf("expected no candidates within 1e-12, got %d", len(tight.Candidates))
+	}
+	if tight.Tried == 0 || tight.Tried != loose.Tried {
+		t.Errorf("tried %d and %d combinations, want the same nonzero count", tight.Tried, loose.Tried)
+	}
+	if !reflect.DeepEqual(tight.Closest, loose.Candidates[0]) {
+		t.Errorf("closest %+v, want the best candidate %+v", tight.Closest, loose.Candidates[0])
+	}
+}
+
+func TestSearchProgress(t *testing.T) {
+	calls := 0
+	last := 0
+	opts := SearchOptions{BothLegs: true, Workers: 4, Progress: func(done, total int) {
+		calls++
+		if done != last+1 || done > total {
+			t.Errorf("progress %d of %d after %d", done, total, last)
+		}
+		last = done
+	}}
+	testBridge.Search(1.0e-4, opts)
+	if want := len(Rvalues) + len(balancePairs(Rvalues, opts)); calls != want {
+		t.Errorf("progress called %d times, want %d", calls, want)
+	}
+}
+
+func TestPassesMatchesSearch(t *testing.T) {
+	const tol = 1.0e-4
+	opts := SearchOptions{WorstCaseTolPct: 0.1}
+	candidates := testBridge.SolveWith(tol, opts)
+	if len(candidates) == 0 {
+		t.Fatal("expected candidates")
+	}
+	for _, c := range candidates {
+		if !opts.Passes(&c, tol) {
+			t.Errorf("candidate %+v from the search does not pass", c)
+		}
+	}
+	npp := testBridge
+	npp.ComputeUnbalance()
+	if opts.Passes(&npp, tol) {
+		t.Error("the unbalanced bridge should not pass")
+	}
+}
+
+func TestSearchAlreadyBalanced(t *testing.T) {
+	for _, npp := range []NPP301{
+		{R1: 1000, R2: 1000, R3: 1000, R4: 1000},
+		{R1: 1000, R2: 1000.01, R3: 1000, R4: 1000},
+	} {
+		result := npp.Search(1.0e-5, SearchOptions{})
+		if !result.AlreadyBalanced || len(result.Candidates) != 1 || result.Candidates[0].Fitted() != 0 {
+			t.Errorf("expected %+v to be reported as already balanced, got %+v", npp, result)
+		}
+	}
+	npp := NPP301{R1: 1000, R2: 1000, R3: 1000, R4: 1000}
+	if result := npp.Search(0.0, SearchOptions{}); !result.AlreadyBalanced {
+		t.Error("expected an exact balance to count with a zero tolerance")
+	}
+	if result := testBridge.Search(1.0e-4, SearchOptions{}); result.AlreadyBalanced {
+		t.Error("the test bridge is not balanced")
+	}
+}
+
+func TestSearchEmit(t *testing.T) {
+	const tol = 1.0e-4
+	want := testBridge.SolveWith(tol, SearchOptions{BothLegs: true})
+	var got []NPP301
+	result := testBridge.Search(tol, SearchOptions{BothLegs: true, Emit: func(c NPP301) { got = append(got, c) }})
+	if len(result.Candidates) != 0 {
+		t.Errorf("expected no candidates collected while emitting, got %d", len(result.Candidates))
+	}
+	SortCandidates(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("emitted %d candidates, want the %d collected", len(got), len(want))
+	}
+}
+
+func TestSolveFunc(t *testing.T) {
+	const tol = 1.0e-4
+	want := testBridge.Solve(tol)
+	var got []NPP301
+	testBridge.SolveFunc(tol, func(c NPP301) bool {
+		got = append(got, c)
+		return true
+	})
+	SortCandidates(got)
+	if len(got) != len(want) {
+		t.Fatalf("got %d candidates, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("candidate %d is %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	// Returning false stops the calls at once.
+	n := 0
+	testBridge.SolveFuncWith(tol, SearchOptions{BothLegs: true}, func(c NPP301) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("fn was called %d times after asking to stop at 3", n)
+	}
+}
+
+func TestSearchValuesPerLeg(t *testing.T) {
+	opts := SearchOptions{ValuesCD: []float64{18.0, 91.0, 100.0}, BothLegs: true}
+	candidates := testBridge.SolveWith(1.0e-4, opts)
+	if len(candidates) == 0 {
+		t.Fatal("expected candidates from the leg 3-4 values")
+	}
+	for _, c := range candidates {
+		for _, R := range []float64{c.RC, c.RD} {
+			if R != 0.0 && R != 18.0 && R != 91.0 && R != 100.0 {
+				t.Errorf("candidate %+v uses RC or RD outside the leg 3-4 values", c)
+			}
+		}
+	}
+}
+
+func TestGrade(t *testing.T) {
+	tests := []struct {
+		v2mv6 float64
+		want  string
+	}{
+		{0.0, "A"}, {-1.0e-5, "A"}, {3.0e-5, "B"}, {-5.0e-5, "B"}, {9.9e-5, "C"},
+	}
+	for _, tc := range tests {
+		npp := NPP301{V2mV6: tc.v2mv6}
+		if got := npp.Grade(0.0, 1.0e-4); got != tc.want {
+			t.Errorf("Grade of %v = %q, want %q", tc.v2mv6, got, tc.want)
+		}
+	}
+}
+
+func TestSolveExcitations(t *testing.T) {
+	// The balance is ratiometric: the output at 5 V is that at 3.3 V scaled.
+	npp := testBridge
+	npp.Vexc = 3.3
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	at5 := npp.WithExcitation(5.0)
+	if want := npp.V2mV6 * 5.0 / 3.3; math.Abs(at5.V2mV6-want) > eps {
+		t.Errorf("v2-v6 at 5 V = %v, want %v", at5.V2mV6, want)
+	}
+	// A candidate must then pass at 5 V too, which is the tighter of the two.
+	const tol = 1.0e-4
+	opts := SearchOptions{Excitations: []float64{5.0}}
+	candidates := npp.SolveWith(tol, opts)
+	if len(candidates) == 0 {
+		t.Fatal("expected candidates that pass at both excitations")
+	}
+	for _, c := range candidates {
+		if at := c.WithExcitation(5.0); math.Abs(at.V2mV6) >= tol {
+			t.Errorf("RC=%v RD=%v: v2-v6 at 5 V = %v, outside %v", c.RC, c.RD, at.V2mV6, tol)
+		}
+	}
+	if len(candidates) >= len(npp.SolveWith(tol, SearchOptions{})) {
+		t.Error("expected the second excitation to rule out some candidates")
+	}
+}
+
+func TestSolveTrimBounds(t *testing.T) {
+	const tol = 1.0e-4
+	all := testBridge.SolveWith(tol, SearchOptions{})
+	opts := SearchOptions{MinTrim: 14.9, MaxTrim: 15.1}
+	bounded := testBridge.SolveWith(tol, opts)
+	if len(bounded) == 0 || len(bounded) >= len(all) {
+		t.Fatalf("got %d candidates within the trim bounds of %d, want fewer but some", len(bounded), len(all))
+	}
+	for _, c := range bounded {
+		if R := c.RCDValue(); R < opts.MinTrim || R > opts.MaxTrim {
+			t.Errorf("RC=%v RD=%v: RCD=%v outside %v..%v", c.RC, c.RD, R, opts.MinTrim, opts.MaxTrim)
+		}
+	}
+}
+
+func TestConductances(t *testing.T) {
+	// The parallel pairs of the search, from conductances, must be
+	// those of ParallelR to the bit, as ComputeUnbalance works them out.
+	values := SeriesValues(E192, -1, 5)
+	g := conductances(values)
+	for i, Ra := range values {
+		for j, Rb := range values[i:] {
+			if got, want := 1.0/(g[i]+g[i+j]), ParallelR(Ra, Rb); got != want {
+				t.Fatalf("%v in parallel with %v is %v from conductances, want %v", Ra, Rb, got, want)
+			}
+		}
+	}
+}
+
+func TestSolveExhaustive(t *testing.T) {
+	// With an infinite tolerance the bare bridge passes, and only an
+	// exhaustive search keeps every combination, as for a histogram.
+	opts := SearchOptions{Single: true, SeriesPairs: true}
+	if got := testBridge.SolveWith(math.Inf(1), opts); len(got) != 1 {
+		t.Errorf("without Exhaustive, got %d candidates, want the bare bridge alone", len(got))
+	}
+	_, tried := bruteForce(testBridge, math.Inf(1), opts)
+	opts.Exhaustive = true
+	result := testBridge.Search(math.Inf(1), opts)
+	if len(result.Candidates) != tried || result.Tried != tried {
+		t.Errorf("Exhaustive kept %d of %d tried, want all %d combinations", len(result.Candidates), result.Tried, tried)
+	}
+	if result.AlreadyBalanced {
+		t.Error("Exhaustive search reported the bridge as already balanced")
+	}
+}
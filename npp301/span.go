@@ -0,0 +1,84 @@
+// span.go
+// Swing of the bridge output over the pressure range.
+
+package npp301
+
+import (
+	"math"
+)
+
+// OutputAt returns v2-v6 at the given pressure, for a sensor whose
+// output rises by sensitivity mV/V per unit pressure on top of
+// the offset computed by ComputeUnbalance.
+func (bridge *NPP301) OutputAt(sensitivity, pressure float64) float64 {
+	return bridge.V2mV6 + sensitivity*1.0e-3*bridge.Excitation()*pressure
+}
+
+// Span returns the lowest and highest bridge output, in volts,
+// over the pressure range pMin to pMax.
+func (bridge *NPP301) Span(sensitivity, pMin, pMax float64) (float64, float64) {
+	vA, vB := bridge.OutputAt(sensitivity, pMin), bridge.OutputAt(sensitivity, pMax)
+	return math.Min(vA, vB), math.Max(vA, vB)
+}
+
+// FullScale returns the swing of the bridge output, in volts,
+// over the pressure range pMin to pMax.
+func (bridge *NPP301) FullScale(sensitivity, pMin, pMax float64) float64 {
+	vMin, vMax := bridge.Span(sensitivity, pMin, pMax)
+	return vMax - vMin
+}
+
+// OffsetPPMFS returns the offset V2mV6 in parts per million of the
+// full-scale swing over the pressure range pMin to pMax, as residual
+// offset is quoted on the data sheets of precision sensors.
+func (bridge *NPP301) OffsetPPMFS(sensitivity, pMin, pMax float64) float64 {
+	return bridge.V2mV6 / bridge.FullScale(sensitivity, pMin, pMax) * 1.0e6
+}
+
+// Gain returns the amplifier gain that maps the bridge output over
+// the pressure range pMin to pMax onto the output range outMin to outMax,
+// together with the offset, that is V2mV6 after that gain.
+// The amplifier reference then needs to shift the output by
+// outMin less the gain times the lowest bridge output.
+func (bridge *NPP301) Gain(sensitivity, pMin, pMax, outMin, outMax float64) (float64, float64) {
+	gain := (outMax - outMin) / bridge.FullScale(sensitivity, pMin, pMax)
+	return gain, gain * bridge.V2mV6
+}
+
+// OffsetLSB returns the offset V2mV6, after the amplifier gain,
+// in codes of an ADC with the reference vref volts and bits of resolution.
+func (bridge *NPP301) OffsetLSB(gain, vref float64, bits int) float64 {
+	lsb := vref / math.Ldexp(1.0, bits)
+	return bridge.V2mV6 * gain / lsb
+}
+
+// StrainGain returns the change in v2-v6, in volts, per unit fractional
+// change of the arms under pressure, taken as the usual pattern of a
+// full bridge: R2 and R3 rising by the fraction as R1 and R4 fall by it.
+// The opposite pattern only changes the sign.  Only the arms themselves
+// change, not their leads nor the balance resistors, which are in
+// series with the arms below them and so dilute the change and lower
+// the gain.  The loading of the ADC input is ignored.
+func (bridge *NPP301) StrainGain() float64 {
+	R1, R2, R3, R4 := bridge.arms()
+	g12 := legStrainGain(R1, -bridge.R1, R2, bridge.R2, bridge.RABValue())
+	g34 := legStrainGain(R3, bridge.R3, R4, -bridge.R4, bridge.RCDValue())
+	return bridge.Excitation() * (g12 - g34)
+}
+
+// legStrainGain returns the rate of change of the fraction of the
+// excitation at the output pin of a leg, (Rb+Rx)/(Rt+Rb+Rx), for the top
+// and bottom arms Rt and Rb changing at the rates dRt and dRb, with the
+// balance resistance Rx in series with Rb.
+func legStrainGain(Rt, dRt, Rb, dRb, Rx float64) float64 {
+	S := Rt + Rb + Rx
+	return (dRb*S - (Rb+Rx)*(dRt+dRb)) / (S * S)
+}
+
+// SensitivityChangePct returns the change, in percent, of the pressure
+// sensitivity of the bridge with its balance resistors from that of the
+// bare bridge, as given by StrainGain; a negative change is lost span.
+func (bridge *NPP301) SensitivityChangePct() float64 {
+	bare := bridge.Bare()
+	return (bridge.StrainGain()/bare.StrainGain() - 1.0) * 100.0
+}
@@ -0,0 +1,77 @@
+package npp301
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpan(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.Vexc = 5.0
+	npp.ComputeUnbalance()
+	// 0.2 mV/V/kPa over 0 to 100 kPa at 5 V swings by 100 mV.
+	vMin, vMax := npp.Span(0.2, 0.0, 100.0)
+	if math.Abs(vMin-npp.V2mV6) > eps || math.Abs(vMax-npp.V2mV6-0.1) > eps {
+		t.Errorf("Span = %v, %v, want %v, %v", vMin, vMax, npp.V2mV6, npp.V2mV6+0.1)
+	}
+	if fs := npp.FullScale(-0.2, 0.0, 100.0); math.Abs(fs-0.1) > eps {
+		t.Errorf("FullScale = %v, want 0.1", fs)
+	}
+	// A falling output still gives the lower value first.
+	vMin, vMax = npp.Span(-0.2, 0.0, 100.0)
+	if vMin >= vMax {
+		t.Errorf("Span with negative sensitivity = %v, %v, want min < max", vMin, vMax)
+	}
+}
+
+func TestGain(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.Vexc = 5.0
+	npp.ComputeUnbalance()
+	// A 100 mV span onto 0 to 3.3 V needs a gain of 33.
+	gain, offset := npp.Gain(0.2, 0.0, 100.0, 0.0, 3.3)
+	if math.Abs(gain-33.0) > 1.0e-9 || math.Abs(offset-33.0*npp.V2mV6) > 1.0e-9 {
+		t.Errorf("Gain = %v, %v, want 33, %v", gain, offset, 33.0*npp.V2mV6)
+	}
+}
+
+func TestOffsetPPMFS(t *testing.T) {
+	// 10 uV on the 100 mV swing of 0.2 mV/V/kPa over 100 kPa at 5 V is 100 ppm.
+	npp := NPP301{Vexc: 5.0, V2mV6: 1.0e-5}
+	if got := npp.OffsetPPMFS(0.2, 0.0, 100.0); math.Abs(got-100.0) > 1.0e-9 {
+		t.Errorf("OffsetPPMFS = %v, want 100", got)
+	}
+}
+
+func TestOffsetLSB(t *testing.T) {
+	// 1 mV through a gain of 10 is 10 codes of a 12-bit ADC on 4.096 V.
+	npp := NPP301{V2mV6: 1.0e-3}
+	if got := npp.OffsetLSB(10.0, 4.096, 12); math.Abs(got-10.0) > 1.0e-9 {
+		t.Errorf("OffsetLSB = %v, want 10", got)
+	}
+}
+
+func TestStrainGain(t *testing.T) {
+	// A bridge of equal arms gives vexc per unit change of the arms.
+	npp := NPP301{R1: 1000, R2: 1000, R3: 1000, R4: 1000, Vexc: 5.0}
+	if got := npp.StrainGain(); math.Abs(got-5.0) > eps {
+		t.Errorf("StrainGain of equal arms = %v, want 5", got)
+	}
+	if got := npp.SensitivityChangePct(); got != 0.0 {
+		t.Errorf("SensitivityChangePct without balance resistors = %v, want 0", got)
+	}
+	// 1000 ohm below R2 takes leg 1-2 from 1/2 to R2/(R1+R2+RAB) = 1/3
+	// per unit change, so the gain falls from 1/2+1/2 to 1/3+1/2.
+	npp.RA = 1000
+	if got, want := npp.SensitivityChangePct(), -100.0/6.0; math.Abs(got-want) > 1.0e-9 {
+		t.Errorf("SensitivityChangePct with RAB=1000 = %v, want %v", got, want)
+	}
+	// A small trim costs little.
+	c := testBridge
+	c.RC, c.RD = 18.0, 91.0
+	if got := c.SensitivityChangePct(); !(got < 0.0 && got > -1.0) {
+		t.Errorf("SensitivityChangePct with RCD=15 = %v, want a small loss", got)
+	}
+}
@@ -0,0 +1,56 @@
+// temperature.go
+// Drift of the bridge output with temperature.
+
+package npp301
+
+import (
+	"math"
+)
+
+// driftSteps is the number of intervals at which Drift samples
+// the temperature range.
+const driftSteps = 20
+
+// AtTemperature returns a copy of the bridge with each resistor shifted
+// according to its temperature coefficient, deltaT degC from calibration.
+func (bridge *NPP301) AtTemperature(deltaT float64) NPP301 {
+	npp := *bridge
+	arms := 1.0 + bridge.TCArms*1.0e-6*deltaT
+	balance := 1.0 + bridge.TCBalance*1.0e-6*deltaT
+	npp.R1 *= arms
+	npp.R2 *= arms
+	npp.R3 *= arms
+	npp.R4 *= arms
+	npp.RA *= balance
+	npp.RB *= balance
+	npp.RC *= balance
+	npp.RD *= balance
+	npp.RA2 *= balance
+	npp.RC2 *= balance
+	return npp
+}
+
+// UnbalanceAt returns v2-v6 at deltaT degC from calibration.
+func (bridge *NPP301) UnbalanceAt(deltaT float64) float64 {
+	npp := bridge.AtTemperature(deltaT)
+	npp.ComputeUnbalance()
+	return npp.V2mV6
+}
+
+// Drift returns the largest |v2-v6| over the temperature range
+// deltaTMin to deltaTMax degC from calibration.
+// Candidates whose balance resistors track the arms give the smaller drift.
+func (bridge *NPP301) Drift(deltaTMin, deltaTMax float64) float64 {
+	worst := 0.0
+	for i := 0; i <= driftSteps; i++ {
+		deltaT := deltaTMin + (deltaTMax-deltaTMin)*float64(i)/driftSteps
+		worst = math.Max(worst, math.Abs(bridge.UnbalanceAt(deltaT)))
+	}
+	return worst
+}
+
+// SortByDrift orders the candidates by their worst output over
+// the temperature range, most stable first.
+func SortByDrift(candidates []NPP301, deltaTMin, deltaTMax float64) {
+	sortByKey(candidates, func(c *NPP301) float64 { return c.Drift(deltaTMin, deltaTMax) })
+}
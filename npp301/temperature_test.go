@@ -0,0 +1,28 @@
+package npp301
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatchedTempcoDoesNotDrift(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.TCArms, npp.TCBalance = 2500.0, 2500.0
+	npp.ComputeUnbalance()
+	for _, deltaT := range []float64{-45.0, 0.0, 60.0} {
+		if got := npp.UnbalanceAt(deltaT); math.Abs(got-npp.V2mV6) > eps {
+			t.Errorf("UnbalanceAt(%v) = %v, want %v", deltaT, got, npp.V2mV6)
+		}
+	}
+}
+
+func TestMismatchedTempcoDrifts(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.TCArms, npp.TCBalance = 2500.0, 100.0
+	npp.ComputeUnbalance()
+	if drift := npp.Drift(-45.0, 60.0); drift <= math.Abs(npp.V2mV6) {
+		t.Errorf("Drift = %v, expected more than the calibration output %v", drift, math.Abs(npp.V2mV6))
+	}
+}
@@ -0,0 +1,141 @@
+// tolerance.go
+// The effect of resistor tolerance on a chosen balance solution.
+
+package npp301
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Stats summarises the bridge output over a set of samples.
+type Stats struct {
+	N            int
+	Mean, StdDev float64
+}
+
+// MonteCarlo samples the bridge output with each fitted balance resistor
+// drawn at random within tolPct percent of its nominal value.
+// With normal false, the draws are uniform over the tolerance band;
+// with normal true, they are Gaussian with the band taken as 3 sigma.
+// The measured arms R1..R4 are held at their values
+// and unfitted balance positions stay unfitted.
+func (bridge *NPP301) MonteCarlo(tolPct float64, n int, normal bool, rng *rand.Rand) Stats {
+	tol := tolPct / 100.0
+	draw := func(R float64) float64 {
+		if normal {
+			return R * (1.0 + rng.NormFloat64()*tol/3.0)
+		}
+		return R * (1.0 + (2.0*rng.Float64()-1.0)*tol)
+	}
+	// Welford's running mean and variance.
+	stats := Stats{N: n}
+	var m2 float64
+	for i := 0; i < n; i++ {
+		npp := *bridge
+		npp.RA, npp.RB = draw(bridge.RA), draw(bridge.RB)
+		npp.RC, npp.RD = draw(bridge.RC), draw(bridge.RD)
+		npp.RA2, npp.RC2 = draw(bridge.RA2), draw(bridge.RC2)
+		npp.ComputeUnbalance()
+		delta := npp.V2mV6 - stats.Mean
+		stats.Mean += delta / float64(i+1)
+		m2 += delta * (npp.V2mV6 - stats.Mean)
+	}
+	if n > 1 {
+		stats.StdDev = math.Sqrt(m2 / float64(n-1))
+	}
+	return stats
+}
+
+// Jittered returns a copy of the bridge with each fitted balance resistor
+// moved to a plausible real value within tolPct percent of its nominal,
+// its output computed.  The shift is fixed by the nominal value and the
+// position, so that, unlike MonteCarlo, a candidate always gives the
+// same answer, and it is less pessimistic than WorstCase.
+func (bridge *NPP301) Jittered(tolPct float64) NPP301 {
+	tol := tolPct / 100.0
+	npp := *bridge
+	for i, R := range []*float64{&npp.RA, &npp.RB, &npp.RC, &npp.RD, &npp.RA2, &npp.RC2} {
+		*R *= 1.0 + tol*jitter(*R, i)
+	}
+	npp.ComputeUnbalance()
+	return npp
+}
+
+// jitter returns a fraction in [-1, 1) that depends only on the
+// resistance R and its position, hashed with the splitmix64 finalizer.
+func jitter(R float64, position int) float64 {
+	x := math.Float64bits(R) + uint64(position+1)*0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11)/float64(1<<52) - 1.0
+}
+
+// WorstCase returns the largest |v2-v6| with each fitted balance resistor
+// at either end of its ±tolPct percent tolerance band.
+// The bridge output is monotonic in each resistor,
+// so the extremes are found at the corners of the band.
+func (bridge *NPP301) WorstCase(tolPct float64) float64 {
+	return bridge.WorstCaseFrom(0.0, tolPct)
+}
+
+// WorstCaseFrom is WorstCase for the largest |v2-v6-target|.
+func (bridge *NPP301) WorstCaseFrom(target, tolPct float64) float64 {
+	tol := tolPct / 100.0
+	worst := 0.0
+	for corner := 0; corner < 64; corner++ {
+		npp := *bridge
+		for i, R := range []*float64{&npp.RA, &npp.RB, &npp.RC, &npp.RD, &npp.RA2, &npp.RC2} {
+			if corner&(1<<i) != 0 {
+				*R *= 1.0 + tol
+			} else {
+				*R *= 1.0 - tol
+			}
+		}
+		if (npp.RA2 == 0.0 && corner&(1<<4) != 0) || (npp.RC2 == 0.0 && corner&(1<<5) != 0) {
+			// Without the series resistors, these corners repeat the others.
+			continue
+		}
+		npp.ComputeUnbalance()
+		worst = math.Max(worst, math.Abs(npp.V2mV6-target))
+	}
+	return worst
+}
+
+// Sensitivities holds d(v2-v6)/dR, in volts per ohm, for each
+// balance resistor, including the second parts RA2 and RC2 in series.
+// It is zero for a resistor that is not fitted.
+type Sensitivities struct {
+	RA, RA2, RB, RC, RC2, RD float64
+}
+
+// sensitivityStep is the relative change in a resistor
+// for the numerical derivatives of Sensitivities.
+const sensitivityStep = 1.0e-6
+
+// Sensitivities returns the change in output with each balance resistor,
+// by central differences.  The candidate with the smallest values is
+// the most forgiving of an imprecise part.
+func (bridge *NPP301) Sensitivities() Sensitivities {
+	derivative := func(R float64, set func(npp *NPP301, R float64)) float64 {
+		if R == 0.0 {
+			return 0.0
+		}
+		h := R * sensitivityStep
+		up, down := *bridge, *bridge
+		set(&up, R+h)
+		set(&down, R-h)
+		up.ComputeUnbalance()
+		down.ComputeUnbalance()
+		return (up.V2mV6 - down.V2mV6) / (2.0 * h)
+	}
+	return Sensitivities{
+		RA:  derivative(bridge.RA, func(npp *NPP301, R float64) { npp.RA = R }),
+		RA2: derivative(bridge.RA2, func(npp *NPP301, R float64) { npp.RA2 = R }),
+		RB:  derivative(bridge.RB, func(npp *NPP301, R float64) { npp.RB = R }),
+		RC:  derivative(bridge.RC, func(npp *NPP301, R float64) { npp.RC = R }),
+		RC2: derivative(bridge.RC2, func(npp *NPP301, R float64) { npp.RC2 = R }),
+		RD:  derivative(bridge.RD, func(npp *NPP301, R float64) { npp.RD = R }),
+	}
+}
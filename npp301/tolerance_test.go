@@ -0,0 +1,119 @@
+package npp301
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMonteCarloZeroTolerance(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	rng := rand.New(rand.NewSource(1))
+	for _, normal := range []bool{false, true} {
+		stats := npp.MonteCarlo(0.0, 100, normal, rng)
+		if math.Abs(stats.Mean-npp.V2mV6) > eps || stats.StdDev > eps {
+			t.Errorf("normal=%v: got mean=%v stddev=%v, want mean=%v stddev=0",
+				normal, stats.Mean, stats.StdDev, npp.V2mV6)
+		}
+	}
+}
+
+func TestMonteCarloSpread(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	rng := rand.New(rand.NewSource(1))
+	narrow := npp.MonteCarlo(1.0, 2000, false, rng)
+	wide := npp.MonteCarlo(5.0, 2000, false, rng)
+	if !(narrow.StdDev > 0.0 && wide.StdDev > 2.0*narrow.StdDev) {
+		t.Errorf("expected the spread to grow with tolerance, got %v then %v", narrow.StdDev, wide.StdDev)
+	}
+}
+
+func TestWorstCaseBoundsSamples(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	if got := npp.WorstCase(0.0); math.Abs(got-math.Abs(npp.V2mV6)) > eps {
+		t.Errorf("WorstCase(0) = %v, want %v", got, math.Abs(npp.V2mV6))
+	}
+	worst := npp.WorstCase(1.0)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		sample := npp
+		sample.RC *= 1.0 + (2.0*rng.Float64()-1.0)*0.01
+		sample.RD *= 1.0 + (2.0*rng.Float64()-1.0)*0.01
+		sample.ComputeUnbalance()
+		if math.Abs(sample.V2mV6) > worst {
+			t.Fatalf("sample %v exceeds the worst case %v", sample.V2mV6, worst)
+		}
+	}
+}
+
+func TestSensitivities(t *testing.T) {
+	npp := testBridge
+	npp.RC = 15.0
+	s := npp.Sensitivities()
+	// With RC alone, v2-v6 falls by R3*Vexc/(R3+R4+RC)^2 per ohm.
+	sum := npp.R3 + npp.R4 + npp.RC
+	want := -npp.R3 / (sum * sum)
+	if math.Abs(s.RC-want) > 1.0e-6*math.Abs(want) {
+		t.Errorf("Sensitivities RC = %v, want %v", s.RC, want)
+	}
+	if s.RA != 0.0 || s.RA2 != 0.0 || s.RB != 0.0 || s.RC2 != 0.0 || s.RD != 0.0 {
+		t.Errorf("expected zero for the resistors not fitted, got %+v", s)
+	}
+	// A second part in series with RC moves the output just as RC does.
+	npp.RC2 = 5.0
+	s = npp.Sensitivities()
+	sum = npp.R3 + npp.R4 + npp.RC + npp.RC2
+	want = -npp.R3 / (sum * sum)
+	if math.Abs(s.RC-want) > 1.0e-6*math.Abs(want) || math.Abs(s.RC2-want) > 1.0e-6*math.Abs(want) {
+		t.Errorf("Sensitivities RC = %v, RC2 = %v, want %v for both", s.RC, s.RC2, want)
+	}
+	npp = testBridge
+	npp.RA, npp.RA2 = 20.0, 3.0
+	s = npp.Sensitivities()
+	// RA below R2 raises v2 by R1*Vexc/(R1+R2+RA+RA2)^2 per ohm.
+	sum = npp.R1 + npp.R2 + npp.RA + npp.RA2
+	want = npp.R1 / (sum * sum)
+	if math.Abs(s.RA-want) > 1.0e-6*want || math.Abs(s.RA2-want) > 1.0e-6*want {
+		t.Errorf("Sensitivities RA = %v, RA2 = %v, want %v for both", s.RA, s.RA2, want)
+	}
+}
+
+func TestJittered(t *testing.T) {
+	npp := testBridge
+	npp.RC, npp.RD = 18.0, 91.0
+	npp.ComputeUnbalance()
+	a, b := npp.Jittered(1.0), npp.Jittered(1.0)
+	if a != b {
+		t.Errorf("Jittered is not repeatable: %+v and %+v", a, b)
+	}
+	for _, pair := range [][2]float64{{npp.RC, a.RC}, {npp.RD, a.RD}} {
+		if d := math.Abs(pair[1]/pair[0] - 1.0); d > 0.01 || d == 0.0 {
+			t.Errorf("jittered %v to %v, want a shift within 1%%", pair[0], pair[1])
+		}
+	}
+	if a.RA != 0.0 || a.RB != 0.0 {
+		t.Error("Jittered fitted an unfitted position")
+	}
+	if math.Abs(a.V2mV6) > npp.WorstCase(1.0) {
+		t.Errorf("jittered output %v is outside the worst case %v", a.V2mV6, npp.WorstCase(1.0))
+	}
+}
+
+func TestSearchJitter(t *testing.T) {
+	const tol = 2.0e-5
+	all := testBridge.SolveWith(tol, SearchOptions{})
+	kept := testBridge.SolveWith(tol, SearchOptions{JitterTolPct: 1.0})
+	if len(kept) == 0 || len(kept) >= len(all) {
+		t.Fatalf("got %d of %d candidates with jitter, expected a nonempty subset", len(kept), len(all))
+	}
+	for _, c := range kept {
+		if j := c.Jittered(1.0); math.Abs(j.V2mV6) >= tol {
+			t.Errorf("candidate %+v fails at its jittered values, %v", c, j.V2mV6)
+		}
+	}
+}